@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	auditCodeFlag   string
+	auditActorFlag  string
+	auditActionFlag string
+	auditSourceFlag string
+	auditSinceFlag  time.Duration
+	auditLimitFlag  int
+)
+
+// AuditCmd représente la commande 'audit'
+var AuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Affiche le journal d'audit des modifications de liens.",
+	Long: `Cette commande affiche les créations, modifications, suppressions et changements
+d'état des liens, du plus récent au plus ancien, avec leur auteur et l'état du lien
+avant et après chaque action.
+
+Exemple:
+  url-shortener audit --code="xyz123"
+  url-shortener audit --code="promo" --domain="go.example.com"
+  url-shortener audit --actor="key:marketing" --action=update --since=24h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if auditLimitFlag < 1 {
+			fmt.Println("Erreur: le flag --limit doit être positif.")
+			os.Exit(1)
+		}
+
+		filter := repository.AuditFilter{
+			ShortCode: auditCodeFlag,
+			Actor:     auditActorFlag,
+			Action:    auditActionFlag,
+			Source:    auditSourceFlag,
+			Limit:     auditLimitFlag,
+		}
+		if auditSinceFlag > 0 {
+			since := time.Now().Add(-auditSinceFlag)
+			filter.Since = &since
+		}
+
+		cfg := cmd2.Cfg
+		// Un code court désigne le lien d'un seul domaine, le domaine principal par défaut.
+		if auditCodeFlag != "" || domainFlag != "" {
+			domain := resolveDomainFlag(cfg)
+			filter.Domain = &domain
+		}
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		auditService := services.NewAuditService(repository.NewAuditRepository(db))
+		events, err := auditService.ListEvents(filter)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la lecture du journal d'audit: %v", err)
+		}
+
+		if len(events) == 0 {
+			fmt.Println("Aucun événement d'audit.")
+			return
+		}
+		for _, event := range events {
+			fmt.Printf("%s  %-8s %-10s %s (%s)\n", event.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				event.Action, event.ShortCode, event.Actor, event.Source)
+			if event.Before != "" {
+				fmt.Printf("    avant : %s\n", event.Before)
+			}
+			if event.After != "" {
+				fmt.Printf("    après : %s\n", event.After)
+			}
+		}
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(AuditCmd)
+	AuditCmd.Flags().StringVar(&auditCodeFlag, "code", "", "Filtre sur le code court")
+	AuditCmd.Flags().StringVar(&auditActorFlag, "actor", "", "Filtre sur l'auteur (ex: key:marketing, cli:alice)")
+	AuditCmd.Flags().StringVar(&auditActionFlag, "action", "", "Filtre sur l'action (create, update, delete, disable, block, enable)")
+	AuditCmd.Flags().StringVar(&auditSourceFlag, "source", "", "Filtre sur l'origine (api ou cli)")
+	AuditCmd.Flags().DurationVar(&auditSinceFlag, "since", 0, "N'affiche que les événements de cette période (ex: 24h)")
+	AuditCmd.Flags().IntVar(&auditLimitFlag, "limit", 50, "Nombre maximal d'événements affichés")
+	addDomainFlag(AuditCmd)
+}
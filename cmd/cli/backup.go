@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/backup"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var backupOutFlag string
+
+// BackupCmd représente la commande 'backup'
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Crée une sauvegarde cohérente de la base de données.",
+	Long: `Cette commande copie la base SQLite dans un nouveau fichier avec 'VACUUM INTO'.
+Elle peut être lancée pendant que 'run-server' tourne : la copie est faite
+dans une transaction de lecture et reste cohérente.
+
+Exemple:
+  url-shortener backup --out="backups/url_shortener.db"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if backupOutFlag == "" {
+			fmt.Println("Erreur: le flag --out est requis.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		if err := backup.Backup(db, backupOutFlag); err != nil {
+			log.Fatalf("FATAL: Échec de la sauvegarde: %v", err)
+		}
+
+		fmt.Printf("Sauvegarde créée avec succès: %s\n", backupOutFlag)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(BackupCmd)
+	BackupCmd.Flags().StringVar(&backupOutFlag, "out", "", "Chemin du fichier de sauvegarde à créer")
+	BackupCmd.MarkFlagRequired("out")
+}
@@ -1,14 +1,20 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	// Pour valider le format de l'URL
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/glebarez/sqlite"
@@ -18,7 +24,22 @@ import (
 	// Driver SQLite pour GORM
 )
 
-var longURLFlag string
+var (
+	longURLFlag       string
+	redirectTypeFlag  int
+	expiresInFlag     time.Duration
+	forwardQueryFlag  bool
+	utmFlags          services.UTMParams
+	passwordFlag      string
+	signedOnlyFlag    bool
+	activeFromFlag    string
+	activeUntilFlag   string
+	variantFlags      []string
+	stickyFlag        bool
+	ruleFlags         []string
+	domainFlag        string
+	linkWorkspaceFlag string
+)
 
 var CreateCmd = &cobra.Command{
 	Use:   "create",
@@ -26,7 +47,9 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com" --variant="a:70:https://example.com/v1" --variant="b:30:https://example.com/v2"
+  url-shortener create --url="https://example.com" --rule="os=ios,url=https://apps.apple.com/app/id123" --rule="os=android,url=https://play.google.com/store/apps/details?id=com.example"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if longURLFlag == "" {
 			fmt.Println("Erreur: le flag --url est requis.")
@@ -41,6 +64,7 @@ Exemple:
 
 		// Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
 
 		// Initialiser la connexion à la base de données SQLite.
 
@@ -63,24 +87,215 @@ Exemple:
 		//  Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
 
 		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'initialiser la politique d'URLs: %v", err)
+		}
+		// Les événements sont mis en file ici et livrés aux webhooks par le serveur.
+		webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+		linkService := services.NewLinkService(linkRepo, repository.NewAuditRepository(db), urlPolicy, webhookService)
+
+		opts := services.LinkOptions{
+			Domain:       domain,
+			RedirectType: redirectTypeFlag,
+			ForwardQuery: forwardQueryFlag,
+			UTM:          utmFlags,
+			Password:     passwordFlag,
+			SignedOnly:   signedOnlyFlag,
+		}
+		if opts.Variants, err = parseVariantFlags(variantFlags); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		opts.StickyVariants = stickyFlag
+		if opts.Rules, err = parseRuleFlags(ruleFlags); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if expiresInFlag > 0 {
+			expiresAt := time.Now().Add(expiresInFlag)
+			opts.ExpiresAt = &expiresAt
+		}
+		if opts.ActiveFrom, err = parseTimeFlag("active-from", activeFromFlag); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if activeUntilFlag != "" {
+			if expiresInFlag > 0 {
+				fmt.Println("Erreur: --expires-in et --active-until ne peuvent pas être utilisés ensemble.")
+				os.Exit(1)
+			}
+			if opts.ExpiresAt, err = parseTimeFlag("active-until", activeUntilFlag); err != nil {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// Le lien est rattaché à l'espace de travail demandé, visible de ses seuls membres.
+		actor := services.CLIActor()
+		if linkWorkspaceFlag != "" {
+			workspace, err := services.NewWorkspaceService(repository.NewWorkspaceRepository(db)).GetWorkspace(linkWorkspaceFlag)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: l'espace de travail '%s' n'existe pas.\n", linkWorkspaceFlag)
+				os.Exit(1)
+			}
+			if err != nil {
+				log.Fatalf("FATAL: impossible de lire l'espace de travail: %v", err)
+			}
+			actor.WorkspaceID = workspace.ID
+		}
 
 		//  Appeler le LinkService et la fonction CreateLink pour créer le lien court.
-		link, err := linkService.CreateLink(longURLFlag)
+		link, err := linkService.CreateLink(actor, longURLFlag, opts)
 		if err != nil {
 			log.Fatalf("FATAL: Échec de la création du lien court: %v", err)
 			os.Exit(1)
 		}
 
-		fullShortURL := fmt.Sprintf("%s/%s", cfg.Server.BaseURL, link.Shortcode)
+		fullShortURL := cfg.ShortURL(link.Domain, link.Shortcode)
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.Shortcode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		printVariants(link.Variants)
+		printRules(link.Rules)
 	},
 }
 
 func init() {
 	cmd2.RootCmd.AddCommand(CreateCmd)
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
+	CreateCmd.Flags().IntVar(&redirectTypeFlag, "redirect-type", 0, "Code de redirection (301, 302, 307 ou 308), par défaut celui du serveur")
+	CreateCmd.Flags().DurationVar(&expiresInFlag, "expires-in", 0, "Durée de validité du lien (ex: 72h), sans expiration par défaut")
+	CreateCmd.Flags().StringVar(&activeFromFlag, "active-from", "", "Date de mise en ligne du lien (RFC 3339, ex: 2026-11-01T09:00:00+01:00)")
+	CreateCmd.Flags().StringVar(&activeUntilFlag, "active-until", "", "Date de fin de validité du lien (RFC 3339), alternative à --expires-in")
+	CreateCmd.Flags().BoolVar(&forwardQueryFlag, "forward-query", false, "Transmet les paramètres de l'URL courte à l'URL longue")
+	CreateCmd.Flags().StringVar(&passwordFlag, "password", "", "Mot de passe protégeant le lien (8 caractères minimum)")
+	CreateCmd.Flags().BoolVar(&signedOnlyFlag, "signed-only", false, "N'accepte que les URLs signées (voir la commande 'sign')")
+	CreateCmd.Flags().StringArrayVar(&variantFlags, "variant", nil, "Variante A/B au format nom:poids:url (répétable, 2 au minimum)")
+	CreateCmd.Flags().BoolVar(&stickyFlag, "sticky-variants", false, "Sert toujours la même variante à un visiteur (cookie)")
+	CreateCmd.Flags().StringArrayVar(&ruleFlags, "rule", nil, "Règle de ciblage au format os=ios,device=mobile,lang=fr,country=FR,url=https://... (répétable, évaluées dans l'ordre)")
+	CreateCmd.Flags().StringVar(&utmFlags.Source, "utm-source", "", "Paramètre utm_source ajouté à l'URL longue")
+	CreateCmd.Flags().StringVar(&utmFlags.Medium, "utm-medium", "", "Paramètre utm_medium ajouté à l'URL longue")
+	CreateCmd.Flags().StringVar(&utmFlags.Campaign, "utm-campaign", "", "Paramètre utm_campaign ajouté à l'URL longue")
+	CreateCmd.Flags().StringVar(&utmFlags.Term, "utm-term", "", "Paramètre utm_term ajouté à l'URL longue")
+	CreateCmd.Flags().StringVar(&utmFlags.Content, "utm-content", "", "Paramètre utm_content ajouté à l'URL longue")
+	CreateCmd.Flags().StringVar(&linkWorkspaceFlag, "workspace", "", "Espace de travail auquel rattacher le lien")
+	addDomainFlag(CreateCmd)
 	CreateCmd.MarkFlagRequired("url")
 }
+
+// addDomainFlag ajoute à une commande le flag --domain désignant le domaine court du lien.
+func addDomainFlag(c *cobra.Command) {
+	c.Flags().StringVar(&domainFlag, "domain", "", "Domaine court du lien (voir 'domains' dans la configuration), vide pour le domaine principal")
+}
+
+// resolveDomainFlag retourne le domaine désigné par --domain, la chaîne vide pour le domaine
+// principal. Un domaine absent de la configuration arrête la commande.
+func resolveDomainFlag(cfg *config.Config) string {
+	domain, ok := cfg.LookupDomain(domainFlag)
+	if !ok {
+		fmt.Printf("Erreur: le domaine '%s' n'est pas configuré.\n", domainFlag)
+		os.Exit(1)
+	}
+	return domain
+}
+
+// parseTimeFlag lit la valeur d'un flag de date au format RFC 3339, nil si elle est vide.
+func parseTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("la date --%s doit être au format RFC 3339 (ex: 2026-11-01T09:00:00+01:00)", name)
+	}
+	return &t, nil
+}
+
+// parseVariantFlags lit les variantes A/B fournies au format nom:poids:url.
+func parseVariantFlags(values []string) ([]services.Variant, error) {
+	variants := make([]services.Variant, 0, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("la variante %q doit être au format nom:poids:url", value)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("le poids de la variante %q doit être un entier", parts[0])
+		}
+		if _, err := url.ParseRequestURI(parts[2]); err != nil {
+			return nil, fmt.Errorf("l'URL de la variante %q n'est pas valide: %v", parts[0], err)
+		}
+		variants = append(variants, services.Variant{Name: parts[0], Weight: weight, LongURL: parts[2]})
+	}
+	return variants, nil
+}
+
+// printVariants affiche la répartition du trafic entre les variantes A/B d'un lien.
+func printVariants(variants []models.LinkVariant) {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	for _, v := range variants {
+		fmt.Printf("Variante %s (%d%%): %s\n", v.Name, v.Weight*100/total, v.LongURL)
+	}
+}
+
+// parseRuleFlags lit les règles de ciblage fournies au format clé=valeur séparées par des virgules.
+// Les clés reconnues sont os, device, lang et country ; url, obligatoire, doit être la dernière car une URL
+// peut contenir des virgules. Les règles sont évaluées dans l'ordre des flags.
+func parseRuleFlags(values []string) ([]services.Rule, error) {
+	rules := make([]services.Rule, 0, len(values))
+	for i, value := range values {
+		criteria, longURL, found := strings.Cut(value, "url=")
+		if !found || longURL == "" {
+			return nil, fmt.Errorf("la règle %q doit se terminer par url=...", value)
+		}
+		if _, err := url.ParseRequestURI(longURL); err != nil {
+			return nil, fmt.Errorf("l'URL de la règle %q n'est pas valide: %v", value, err)
+		}
+		rule := services.Rule{Priority: i + 1, LongURL: longURL}
+		for _, criterion := range strings.Split(strings.TrimSuffix(criteria, ","), ",") {
+			if criterion == "" {
+				continue
+			}
+			key, val, _ := strings.Cut(criterion, "=")
+			switch key {
+			case "os":
+				rule.OS = val
+			case "device":
+				rule.Device = val
+			case "lang":
+				rule.Language = val
+			case "country":
+				rule.Country = val
+			default:
+				return nil, fmt.Errorf("critère inconnu %q dans la règle %q (os, device, lang ou country)", key, value)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// printRules affiche les règles de ciblage d'un lien dans leur ordre d'évaluation.
+func printRules(rules []models.TargetingRule) {
+	for _, r := range rules {
+		var criteria []string
+		if r.OS != "" {
+			criteria = append(criteria, "os="+r.OS)
+		}
+		if r.Device != "" {
+			criteria = append(criteria, "device="+r.Device)
+		}
+		if r.Language != "" {
+			criteria = append(criteria, "lang="+r.Language)
+		}
+		if r.Country != "" {
+			criteria = append(criteria, "country="+r.Country)
+		}
+		fmt.Printf("Règle %d (%s): %s\n", r.Priority, strings.Join(criteria, ", "), r.LongURL)
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var deleteCodeFlag string
+
+// DeleteCmd représente la commande 'delete'
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime un lien court.",
+	Long: `Cette commande supprime un lien : il répond ensuite 404. Ses statistiques sont
+conservées et son code court n'est jamais réattribué.
+
+Exemple:
+  url-shortener delete --code="xyz123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if deleteCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+		linkService := services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), nil, webhookService)
+		if err := linkService.DeleteLink(services.CLIActor(), domain, deleteCodeFlag); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", deleteCodeFlag)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la suppression du lien: %v", err)
+		}
+
+		fmt.Printf("Lien %s supprimé.\n", deleteCodeFlag)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(DeleteCmd)
+	DeleteCmd.Flags().StringVar(&deleteCodeFlag, "code", "", "Code de l'URL courte à supprimer")
+	addDomainFlag(DeleteCmd)
+	DeleteCmd.MarkFlagRequired("code")
+}
@@ -5,7 +5,7 @@ import (
 	"log"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"github.com/glebarez/sqlite"
 	"github.com/spf13/cobra"
 	"gorm.io/gorm"
@@ -34,8 +34,8 @@ var MigrateCmd = &cobra.Command{
 
 		defer sqlDB.Close()
 
-		// Exécute les migrations automatiques de GORM.
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		// Exécute les migrations automatiques de GORM et enregistre la version du schéma.
+		err = database.Migrate(db)
 		if err != nil {
 			log.Fatalf("FATAL: impossible d'exécuter les migrations: %v", err)
 		}
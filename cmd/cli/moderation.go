@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	statusCodeFlag   string
+	statusReasonFlag string
+	disableBlockFlag bool
+)
+
+// DisableCmd représente la commande 'disable'
+var DisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Désactive (ou bloque) un lien court.",
+	Long: `Cette commande désactive un lien : il répond alors 410 au lieu de rediriger.
+Avec --block, le lien est bloqué pour abus et répond 451.
+
+Exemple:
+  url-shortener disable --code="xyz123" --reason="campagne terminée"
+  url-shortener disable --code="xyz123" --block --reason="phishing"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		status := models.LinkStatusDisabled
+		if disableBlockFlag {
+			status = models.LinkStatusBlocked
+		}
+		setLinkStatus(status)
+	},
+}
+
+// EnableCmd représente la commande 'enable'
+var EnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Réactive un lien court désactivé ou bloqué.",
+	Long: `Cette commande remet un lien désactivé ou bloqué dans l'état actif.
+
+Exemple:
+  url-shortener enable --code="xyz123" --reason="faux positif"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLinkStatus(models.LinkStatusActive)
+	},
+}
+
+// setLinkStatus applique le nouvel état au lien désigné par --code et trace le changement.
+func setLinkStatus(status string) {
+	if statusCodeFlag == "" {
+		fmt.Println("Erreur: le flag --code est requis.")
+		os.Exit(1)
+	}
+
+	cfg := cmd2.Cfg
+	domain := resolveDomainFlag(cfg)
+
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+	}
+
+	// Récupère la connexion SQL sous-jacente
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+	}
+
+	defer sqlDB.Close()
+
+	webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+	moderation := services.NewModerationService(repository.NewLinkRepository(db), repository.NewReportRepository(db), repository.NewAuditRepository(db), webhookService)
+
+	link, err := moderation.SetLinkStatus(domain, statusCodeFlag, status, services.CLIActor(), statusReasonFlag)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", statusCodeFlag)
+			os.Exit(1)
+		}
+		log.Fatalf("FATAL: Échec du changement d'état du lien: %v", err)
+	}
+
+	fmt.Printf("Lien %s : état '%s'.\n", link.Shortcode, link.Status)
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(DisableCmd)
+	cmd2.RootCmd.AddCommand(EnableCmd)
+	for _, c := range []*cobra.Command{DisableCmd, EnableCmd} {
+		c.Flags().StringVar(&statusCodeFlag, "code", "", "Code de l'URL courte")
+		c.Flags().StringVar(&statusReasonFlag, "reason", "", "Raison du changement d'état, conservée dans l'historique")
+		addDomainFlag(c)
+		c.MarkFlagRequired("code")
+	}
+	DisableCmd.Flags().BoolVar(&disableBlockFlag, "block", false, "Bloque le lien pour abus (451) au lieu de le désactiver (410)")
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	eraseIPFlag       string
+	eraseVisitorFlag  string
+	purgeCodeFlag     string
+	anonymizeDaysFlag int
+	privacyDryRunFlag bool
+)
+
+// EraseCmd représente la commande 'erase'
+var EraseCmd = &cobra.Command{
+	Use:   "erase",
+	Short: "Efface les clics d'un visiteur (droit à l'effacement).",
+	Long: `Cette commande supprime tous les clics enregistrés pour une adresse IP ou pour un
+identifiant de visiteur haché (mode analytics.ip_mode=hashed), et retire cette adresse des
+signalements d'abus. Avec --dry-run, elle affiche seulement le nombre de lignes concernées.
+
+Exemple:
+  url-shortener erase --ip="203.0.113.7" --dry-run
+  url-shortener erase --visitor-id="9f86d081884c7d659a2feaa0c55ad015"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if eraseIPFlag == "" && eraseVisitorFlag == "" {
+			fmt.Println("Erreur: le flag --ip ou --visitor-id est requis.")
+			os.Exit(1)
+		}
+		runPrivacyAction(func(privacy *services.PrivacyService) (*services.PrivacyResult, error) {
+			return privacy.EraseVisitor(services.CLIActor(), eraseIPFlag, eraseVisitorFlag, privacyDryRunFlag)
+		})
+	},
+}
+
+// PurgeCmd représente la commande 'purge'
+var PurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Supprime définitivement un lien et tous ses clics.",
+	Long: `Cette commande supprime définitivement un lien, même déjà supprimé, avec ses clics, ses
+statistiques agrégées, ses versions, variantes, règles, changements programmés et signalements.
+Seul le journal d'audit, en ajout seul, est conservé.
+
+Exemple:
+  url-shortener purge --code="xyz123" --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if purgeCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+		domain := resolveDomainFlag(cmd2.Cfg)
+		runPrivacyAction(func(privacy *services.PrivacyService) (*services.PrivacyResult, error) {
+			return privacy.PurgeLink(services.CLIActor(), domain, purgeCodeFlag, privacyDryRunFlag)
+		})
+	},
+}
+
+// AnonymizeCmd représente la commande 'anonymize'
+var AnonymizeCmd = &cobra.Command{
+	Use:   "anonymize",
+	Short: "Efface l'adresse IP des anciens clics.",
+	Long: `Cette commande efface l'adresse IP des clics et des signalements plus anciens que
+--days jours (par défaut privacy.anonymize_after_days). Les clics restent comptés.
+
+Exemple:
+  url-shortener anonymize --days=30 --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		days := anonymizeDaysFlag
+		if days == 0 {
+			days = cmd2.Cfg.Privacy.AnonymizeAfterDays
+		}
+		runPrivacyAction(func(privacy *services.PrivacyService) (*services.PrivacyResult, error) {
+			return privacy.AnonymizeOlderThan(services.CLIActor(), days, privacyDryRunFlag)
+		})
+	},
+}
+
+// runPrivacyAction ouvre la base, exécute une action de protection des données et affiche
+// le nombre de lignes touchées (ou qui le seraient, en simulation).
+func runPrivacyAction(action func(privacy *services.PrivacyService) (*services.PrivacyResult, error)) {
+	cfg := cmd2.Cfg
+
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+	}
+
+	// Récupère la connexion SQL sous-jacente
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+	}
+
+	defer sqlDB.Close()
+
+	// L'anonymiseur des clics permet de retrouver l'empreinte d'une IP en mode hashed.
+	anonymizer, err := netutil.NewIPAnonymizer(cfg.Analytics.IPMode, cfg.Analytics.IPHashSecret)
+	if err != nil {
+		log.Fatalf("FATAL: configuration analytics.ip_mode invalide: %v", err)
+	}
+	if anonymizer.Mode() == netutil.IPModeHashed && cfg.Analytics.IPHashSecret == "" {
+		fmt.Println("Attention: analytics.ip_hash_secret n'est pas défini, les empreintes des IP ne peuvent pas être retrouvées.")
+	}
+
+	privacy := services.NewPrivacyService(repository.NewPrivacyRepository(db), repository.NewAuditRepository(db), anonymizer)
+	result, err := action(privacy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", purgeCodeFlag)
+			os.Exit(1)
+		}
+		if errors.Is(err, services.ErrInvalidIP) || errors.Is(err, services.ErrInvalidAge) {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		log.Fatalf("FATAL: Échec de l'opération: %v", err)
+	}
+
+	if result.DryRun {
+		fmt.Println("Simulation : aucune donnée n'a été modifiée. Lignes concernées :")
+	} else {
+		fmt.Println("Opération effectuée et tracée dans le journal d'audit. Lignes touchées :")
+	}
+	printErasureCounts(result.Counts)
+}
+
+// printErasureCounts affiche les décomptes non nuls d'une action de protection des données.
+func printErasureCounts(counts repository.ErasureCounts) {
+	rows := []struct {
+		label string
+		count int64
+	}{
+		{"Liens", counts.Links},
+		{"Clics", counts.Clicks},
+		{"Agrégats de clics", counts.ClickRollups},
+		{"Versions", counts.Versions},
+		{"Variantes", counts.Variants},
+		{"Règles de ciblage", counts.Rules},
+		{"Changements programmés", counts.ScheduledChanges},
+		{"Signalements", counts.Reports},
+		{"Changements d'état", counts.StatusChanges},
+	}
+	printed := false
+	for _, row := range rows {
+		if row.count > 0 {
+			fmt.Printf("  %s: %d\n", row.label, row.count)
+			printed = true
+		}
+	}
+	if !printed {
+		fmt.Println("  aucune")
+	}
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(EraseCmd)
+	cmd2.RootCmd.AddCommand(PurgeCmd)
+	cmd2.RootCmd.AddCommand(AnonymizeCmd)
+
+	EraseCmd.Flags().StringVar(&eraseIPFlag, "ip", "", "Adresse IP du visiteur")
+	EraseCmd.Flags().StringVar(&eraseVisitorFlag, "visitor-id", "", "Identifiant haché du visiteur (mode ip_mode=hashed)")
+	PurgeCmd.Flags().StringVar(&purgeCodeFlag, "code", "", "Code de l'URL courte à purger")
+	addDomainFlag(PurgeCmd)
+	PurgeCmd.MarkFlagRequired("code")
+	AnonymizeCmd.Flags().IntVar(&anonymizeDaysFlag, "days", 0, "Âge minimal, en jours, des clics à anonymiser (défaut : privacy.anonymize_after_days)")
+
+	for _, c := range []*cobra.Command{EraseCmd, PurgeCmd, AnonymizeCmd} {
+		c.Flags().BoolVar(&privacyDryRunFlag, "dry-run", false, "Affiche le nombre de lignes concernées sans rien modifier")
+	}
+}
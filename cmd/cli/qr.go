@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/qrcode"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	qrCodeFlag string
+	qrOutFlag  string
+	qrSizeFlag int
+	qrECCFlag  string
+)
+
+// QRCmd représente la commande 'qr'
+var QRCmd = &cobra.Command{
+	Use:   "qr",
+	Short: "Génère le QR code d'un lien court dans un fichier PNG ou SVG.",
+	Long: `Cette commande génère le QR code de l'URL courte complète d'un lien.
+Le format est déduit de l'extension du fichier de sortie (.png ou .svg).
+Les scans du QR code sont comptés avec la source "qr" dans les statistiques.
+
+Exemple:
+  url-shortener qr --code="xyz123" --out="affiche.png" --size=512 --ecc=H`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if qrCodeFlag == "" || qrOutFlag == "" {
+			fmt.Println("Erreur: les flags --code et --out sont requis.")
+			os.Exit(1)
+		}
+
+		level, err := qrcode.ParseLevel(qrECCFlag)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(qrOutFlag)), ".")
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		linkRepo := repository.NewLinkRepository(db)
+		linkService := services.NewLinkService(linkRepo, nil, nil, nil)
+
+		link, err := linkService.GetLinkByShortCode(domain, qrCodeFlag)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", qrCodeFlag)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la récupération du lien: %v", err)
+		}
+
+		content := services.QRCodeURL(cfg.BaseURLFor(link.Domain), link.Shortcode)
+		image, _, err := services.RenderQRCode(content, format, qrSizeFlag, level)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la génération du QR code: %v", err)
+		}
+
+		if err := os.WriteFile(qrOutFlag, image, 0o644); err != nil {
+			log.Fatalf("FATAL: impossible d'écrire le fichier %s: %v", qrOutFlag, err)
+		}
+
+		fmt.Printf("QR code de %s écrit dans %s\n", content, qrOutFlag)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(QRCmd)
+	QRCmd.Flags().StringVar(&qrCodeFlag, "code", "", "Code de l'URL courte à encoder")
+	QRCmd.Flags().StringVar(&qrOutFlag, "out", "", "Fichier de sortie (.png ou .svg)")
+	QRCmd.Flags().IntVar(&qrSizeFlag, "size", services.DefaultQRSize, "Largeur de l'image en pixels")
+	QRCmd.Flags().StringVar(&qrECCFlag, "ecc", "M", "Niveau de correction d'erreurs (L, M, Q ou H)")
+	addDomainFlag(QRCmd)
+	QRCmd.MarkFlagRequired("code")
+	QRCmd.MarkFlagRequired("out")
+}
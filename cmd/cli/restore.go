@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/backup"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var restoreFromFlag string
+
+// RestoreCmd représente la commande 'restore'
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restaure la base de données à partir d'une sauvegarde.",
+	Long: `Cette commande vérifie l'intégrité et la version du schéma d'une sauvegarde,
+puis remplace le fichier de base configuré par celle-ci.
+Arrêtez 'run-server' avant de lancer une restauration.
+
+Exemple:
+  url-shortener restore --from="backups/url_shortener.db"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if restoreFromFlag == "" {
+			fmt.Println("Erreur: le flag --from est requis.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+
+		version, err := backup.Restore(cfg.Database.Name, restoreFromFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la restauration: %v", err)
+		}
+
+		fmt.Printf("Base de données restaurée depuis %s (schéma v%d).\n", restoreFromFlag, version)
+		if version < database.SchemaVersion {
+			fmt.Printf("Lancez 'url-shortener migrate' pour mettre le schéma à jour (v%d).\n", database.SchemaVersion)
+		}
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(RestoreCmd)
+	RestoreCmd.Flags().StringVar(&restoreFromFlag, "from", "", "Chemin du fichier de sauvegarde à restaurer")
+	RestoreCmd.MarkFlagRequired("from")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	rollbackCodeFlag string
+	rollbackToFlag   int
+)
+
+// RollbackCmd représente la commande 'rollback'
+var RollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Affiche l'historique des destinations d'un lien ou rétablit une version précédente.",
+	Long: `Sans --to, cette commande liste les versions de la destination d'un lien.
+Avec --to, elle rétablit la destination de la version indiquée ; le retour en arrière
+crée une nouvelle version, l'historique n'est jamais réécrit.
+
+Exemple:
+  url-shortener rollback --code="xyz123"
+  url-shortener rollback --code="xyz123" --to=2`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if rollbackCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'initialiser la politique d'URLs: %v", err)
+		}
+		webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+		linkService := services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), urlPolicy, webhookService)
+
+		if !cmd.Flags().Changed("to") {
+			link, versions, err := linkService.GetLinkVersions(services.CLIActor(), domain, rollbackCodeFlag)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", rollbackCodeFlag)
+					os.Exit(1)
+				}
+				log.Fatalf("FATAL: Échec de la lecture des versions du lien: %v", err)
+			}
+
+			fmt.Printf("Versions de la destination du lien %s:\n", link.Shortcode)
+			for _, v := range versions {
+				marker := " "
+				if v.Version == link.Version {
+					marker = "*"
+				}
+				fmt.Printf("%s v%-3d %s  %s (%s)\n", marker, v.Version, v.CreatedAt.Local().Format("2006-01-02 15:04:05"), v.LongURL, v.CreatedBy)
+			}
+			return
+		}
+
+		link, err := linkService.RollbackLink(services.CLIActor(), domain, rollbackCodeFlag, rollbackToFlag)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", rollbackCodeFlag)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrVersionNotFound) || errors.Is(err, services.ErrVersionAlreadyActive) {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec du retour en arrière: %v", err)
+		}
+
+		fmt.Printf("Lien %s : destination de la version %d rétablie (nouvelle version %d).\n", link.Shortcode, rollbackToFlag, link.Version)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(RollbackCmd)
+	RollbackCmd.Flags().StringVar(&rollbackCodeFlag, "code", "", "Code de l'URL courte")
+	RollbackCmd.Flags().IntVar(&rollbackToFlag, "to", 0, "Version dont la destination doit être rétablie")
+	addDomainFlag(RollbackCmd)
+	RollbackCmd.MarkFlagRequired("code")
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/retention"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var rollupDaysFlag int
+
+// RollupCmd représente la commande 'rollup'
+var RollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Agrège immédiatement les clics bruts plus anciens que la durée de rétention.",
+	Long: `Cette commande exécute une passe de rétention : les clics plus anciens que
+N jours sont regroupés par lien et par jour dans 'click_daily_rollups', puis supprimés
+de la table 'clicks'. Les statistiques restent identiques après l'agrégation.
+
+Exemple:
+  url-shortener rollup --days=30`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+
+		days := cfg.Retention.RawDays
+		if rollupDaysFlag > 0 {
+			days = rollupDaysFlag
+		}
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		clickRepo := repository.NewClickRepository(db)
+		retentionInterval := time.Duration(cfg.Retention.IntervalMinutes) * time.Minute
+		roller, err := retention.NewRoller(clickRepo, days, retentionInterval, cfg.Retention.BatchSize)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		count, err := roller.RunOnce(time.Now())
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'agrégation des clics: %v", err)
+		}
+
+		fmt.Printf("%d clic(s) de plus de %d jour(s) agrégé(s).\n", count, days)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(RollupCmd)
+	RollupCmd.Flags().IntVar(&rollupDaysFlag, "days", 0, "Âge en jours au-delà duquel les clics sont agrégés (par défaut: retention.raw_days)")
+}
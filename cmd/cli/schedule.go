@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	scheduleCodeFlag   string
+	scheduleAtFlag     string
+	scheduleURLFlag    string
+	scheduleCancelFlag uint
+)
+
+// ScheduleCmd représente la commande 'schedule'
+var ScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Programme, liste ou annule les changements de destination d'un lien.",
+	Long: `Cette commande gère les changements de destination programmés d'un lien.
+Sans autre flag que --code, elle liste les changements du lien. Avec --at et --url,
+elle programme un nouveau changement, appliqué par le serveur à l'échéance.
+Avec --cancel, elle annule un changement encore en attente.
+
+Exemple:
+  url-shortener schedule --code="xyz123"
+  url-shortener schedule --code="xyz123" --at="2026-12-01T00:00:00+01:00" --url="https://example.com/soldes"
+  url-shortener schedule --code="xyz123" --cancel=4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if scheduleCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+		adding := scheduleAtFlag != "" || scheduleURLFlag != ""
+		if adding && (scheduleAtFlag == "" || scheduleURLFlag == "") {
+			fmt.Println("Erreur: les flags --at et --url doivent être fournis ensemble.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'initialiser la politique d'URLs: %v", err)
+		}
+		scheduleService := services.NewScheduleService(repository.NewLinkRepository(db),
+			repository.NewScheduleRepository(db), repository.NewAuditRepository(db), urlPolicy, nil)
+
+		switch {
+		case cmd.Flags().Changed("cancel"):
+			err = scheduleService.CancelChange(services.CLIActor(), domain, scheduleCodeFlag, scheduleCancelFlag)
+			if err == nil {
+				fmt.Printf("Changement #%d annulé.\n", scheduleCancelFlag)
+			}
+
+		case adding:
+			at, parseErr := parseTimeFlag("at", scheduleAtFlag)
+			if parseErr != nil {
+				fmt.Printf("Erreur: %v\n", parseErr)
+				os.Exit(1)
+			}
+			change, scheduleErr := scheduleService.ScheduleChange(services.CLIActor(), domain, scheduleCodeFlag, *at, scheduleURLFlag)
+			if err = scheduleErr; err == nil {
+				fmt.Printf("Changement #%d programmé pour le %s vers %s.\n",
+					change.ID, change.At.Local().Format("2006-01-02 15:04:05"), change.LongURL)
+			}
+
+		default:
+			link, changes, listErr := scheduleService.ListChanges(services.CLIActor(), domain, scheduleCodeFlag)
+			if err = listErr; err == nil {
+				fmt.Printf("Changements programmés du lien %s:\n", link.Shortcode)
+				if len(changes) == 0 {
+					fmt.Println("Aucun changement programmé.")
+				}
+				for _, change := range changes {
+					fmt.Printf("#%-4d %s  %-9s %s\n", change.ID,
+						change.At.Local().Format("2006-01-02 15:04:05"), change.Status, change.LongURL)
+					if change.Error != "" {
+						fmt.Printf("      erreur : %s\n", change.Error)
+					}
+				}
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", scheduleCodeFlag)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrScheduleInPast) || errors.Is(err, services.ErrScheduleNotFound) ||
+				errors.Is(err, services.ErrScheduleNotPending) || errors.Is(err, services.ErrURLRejected) {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la gestion des changements programmés: %v", err)
+		}
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(ScheduleCmd)
+	ScheduleCmd.Flags().StringVar(&scheduleCodeFlag, "code", "", "Code de l'URL courte")
+	ScheduleCmd.Flags().StringVar(&scheduleAtFlag, "at", "", "Échéance du changement (RFC 3339)")
+	ScheduleCmd.Flags().StringVar(&scheduleURLFlag, "url", "", "Nouvelle destination à l'échéance")
+	ScheduleCmd.Flags().UintVar(&scheduleCancelFlag, "cancel", 0, "Identifiant du changement à annuler")
+	addDomainFlag(ScheduleCmd)
+	ScheduleCmd.MarkFlagRequired("code")
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/signing"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	signCodeFlag string
+	signTTLFlag  time.Duration
+)
+
+// SignCmd représente la commande 'sign'
+var SignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Génère une URL signée et à durée de vie limitée pour un lien en mode signé.",
+	Long: `Cette commande génère une URL courte portant une date d'expiration et une signature HMAC
+(?exp=...&sig=...), calculée avec la clé active de la section 'signing' de la configuration.
+Le lien doit avoir été créé en mode signé.
+
+Exemple:
+  url-shortener sign --code="xyz123" --ttl=48h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if signCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		signer, err := signing.NewSigner(cfg.SigningKeys(), cfg.Signing.ActiveKeyID)
+		if err != nil {
+			log.Fatalf("FATAL: configuration de signature invalide: %v", err)
+		}
+		if !signer.Enabled() {
+			fmt.Println("Erreur: aucune clé de signature n'est configurée (section 'signing.keys').")
+			os.Exit(1)
+		}
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		linkRepo := repository.NewLinkRepository(db)
+		linkService := services.NewLinkService(linkRepo, nil, nil, nil)
+
+		link, err := linkService.GetLinkByShortCode(domain, signCodeFlag)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", signCodeFlag)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la récupération du lien: %v", err)
+		}
+
+		maxTTL := time.Duration(cfg.Signing.MaxTTLHours) * time.Hour
+		signedURL, expires, err := services.SignedShortURL(signer, cfg.BaseURLFor(link.Domain), link, signTTLFlag, maxTTL)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la signature du lien: %v", err)
+		}
+
+		fmt.Printf("URL signée: %s\n", signedURL)
+		fmt.Printf("Expire le: %s\n", expires.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(SignCmd)
+	SignCmd.Flags().StringVar(&signCodeFlag, "code", "", "Code de l'URL courte à signer")
+	SignCmd.Flags().DurationVar(&signTTLFlag, "ttl", 24*time.Hour, "Durée de validité de l'URL signée")
+	addDomainFlag(SignCmd)
+	SignCmd.MarkFlagRequired("code")
+}
@@ -33,6 +33,7 @@ Exemple:
 
 		//  Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
 
 		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
 		if err != nil {
@@ -49,10 +50,10 @@ Exemple:
 
 		//  Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
 		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		linkService := services.NewLinkService(linkRepo, nil, nil, nil)
 
 		// Récupérer les statistiques du lien via le service
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, totalClicks, err := linkService.GetLinkStats(services.CLIActor(), domain, shortCodeFlag)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCodeFlag)
@@ -64,6 +65,21 @@ Exemple:
 		fmt.Printf("Statistiques pour le code court: %s\n", link.Shortcode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
 		fmt.Printf("Total de clics: %d\n", totalClicks)
+
+		// Répartition des clics d'un test A/B
+		if link.HasVariants() {
+			byVariant, err := linkService.GetClicksByVariant(link)
+			if err != nil {
+				log.Fatalf("FATAL: Échec de la récupération des clics par variante: %v", err)
+			}
+			for _, row := range byVariant {
+				name := row.Variant
+				if name == "" {
+					name = "(destination principale)"
+				}
+				fmt.Printf("  Variante %s: %d clic(s)\n", name, row.Count)
+			}
+		}
 	},
 }
 
@@ -72,6 +88,7 @@ func init() {
 	//  Définir le flag --code pour la commande stats.
 	StatsCmd.Flags().StringVar(&shortCodeFlag, "code", "", "Code de l'URL courte pour laquelle récupérer les statistiques")
 	// Marquer le flag comme requis
+	addDomainFlag(StatsCmd)
 	StatsCmd.MarkFlagRequired("code")
 
 }
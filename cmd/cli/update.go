@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	updateCodeFlag         string
+	updateURLFlag          string
+	updateRedirectTypeFlag int
+	updateActiveFromFlag   string
+	updateActiveUntilFlag  string
+	updateClearActiveFrom  bool
+	updateClearActiveUntil bool
+	updateVariantFlags     []string
+	updateClearVariants    bool
+	updateStickyFlag       bool
+	updateRuleFlags        []string
+	updateClearRules       bool
+)
+
+// UpdateCmd représente la commande 'update'
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie la destination ou le code de redirection d'un lien court.",
+	Long: `Cette commande modifie un lien existant. Seuls les flags fournis sont appliqués.
+
+Exemple:
+  url-shortener update --code="xyz123" --redirect-type=301
+  url-shortener update --code="xyz123" --url="https://www.example.com/nouvelle-page"
+  url-shortener update --code="xyz123" --variant="a:50:https://example.com/v1" --variant="b:50:https://example.com/v2"
+  url-shortener update --code="xyz123" --clear-variants
+  url-shortener update --code="xyz123" --clear-active-until
+  url-shortener update --code="xyz123" --rule="device=desktop,url=https://www.example.com"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if updateCodeFlag == "" {
+			fmt.Println("Erreur: le flag --code est requis.")
+			os.Exit(1)
+		}
+
+		var update services.LinkUpdate
+		if cmd.Flags().Changed("url") {
+			if _, err := url.ParseRequestURI(updateURLFlag); err != nil {
+				fmt.Printf("Erreur: l'URL fournie n'est pas valide: %v\n", err)
+				os.Exit(1)
+			}
+			update.LongURL = &updateURLFlag
+		}
+		if cmd.Flags().Changed("redirect-type") {
+			update.RedirectType = &updateRedirectTypeFlag
+		}
+		var err error
+		if update.ActiveFrom, err = parseTimeFlag("active-from", updateActiveFromFlag); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if update.ExpiresAt, err = parseTimeFlag("active-until", updateActiveUntilFlag); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if (updateClearActiveFrom && update.ActiveFrom != nil) || (updateClearActiveUntil && update.ExpiresAt != nil) {
+			fmt.Println("Erreur: une date ne peut pas être fixée et retirée en même temps.")
+			os.Exit(1)
+		}
+		update.ClearActiveFrom = updateClearActiveFrom
+		update.ClearExpiresAt = updateClearActiveUntil
+
+		if len(updateVariantFlags) > 0 && updateClearVariants {
+			fmt.Println("Erreur: --variant et --clear-variants ne peuvent pas être utilisés ensemble.")
+			os.Exit(1)
+		}
+		if len(updateVariantFlags) > 0 || updateClearVariants {
+			variants, err := parseVariantFlags(updateVariantFlags)
+			if err != nil {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			update.Variants = &variants
+		}
+		if cmd.Flags().Changed("sticky-variants") {
+			update.StickyVariants = &updateStickyFlag
+		}
+		if len(updateRuleFlags) > 0 && updateClearRules {
+			fmt.Println("Erreur: --rule et --clear-rules ne peuvent pas être utilisés ensemble.")
+			os.Exit(1)
+		}
+		if len(updateRuleFlags) > 0 || updateClearRules {
+			rules, err := parseRuleFlags(updateRuleFlags)
+			if err != nil {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			update.Rules = &rules
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		linkRepo := repository.NewLinkRepository(db)
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'initialiser la politique d'URLs: %v", err)
+		}
+		webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+		linkService := services.NewLinkService(linkRepo, repository.NewAuditRepository(db), urlPolicy, webhookService)
+
+		link, err := linkService.UpdateLink(services.CLIActor(), domain, updateCodeFlag, update)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", updateCodeFlag)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la modification du lien: %v", err)
+		}
+
+		fmt.Printf("Lien %s modifié avec succès:\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if link.RedirectType != 0 {
+			fmt.Printf("Code de redirection: %d\n", link.RedirectType)
+		} else {
+			fmt.Printf("Code de redirection: défaut du serveur (%d)\n", cfg.Server.DefaultRedirectType)
+		}
+		printVariants(link.Variants)
+		printRules(link.Rules)
+	},
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(UpdateCmd)
+	UpdateCmd.Flags().StringVar(&updateCodeFlag, "code", "", "Code de l'URL courte à modifier")
+	UpdateCmd.Flags().StringVar(&updateURLFlag, "url", "", "Nouvelle URL longue de destination")
+	UpdateCmd.Flags().IntVar(&updateRedirectTypeFlag, "redirect-type", 0, "Code de redirection (301, 302, 307 ou 308, 0 pour le défaut du serveur)")
+	UpdateCmd.Flags().StringVar(&updateActiveFromFlag, "active-from", "", "Nouvelle date de mise en ligne (RFC 3339)")
+	UpdateCmd.Flags().StringVar(&updateActiveUntilFlag, "active-until", "", "Nouvelle date de fin de validité (RFC 3339)")
+	UpdateCmd.Flags().BoolVar(&updateClearActiveFrom, "clear-active-from", false, "Retire la date de mise en ligne du lien")
+	UpdateCmd.Flags().BoolVar(&updateClearActiveUntil, "clear-active-until", false, "Retire la date de fin de validité : le lien redevient permanent")
+	UpdateCmd.Flags().StringArrayVar(&updateVariantFlags, "variant", nil, "Remplace les variantes A/B, au format nom:poids:url (répétable)")
+	UpdateCmd.Flags().BoolVar(&updateClearVariants, "clear-variants", false, "Retire toutes les variantes A/B du lien")
+	UpdateCmd.Flags().BoolVar(&updateStickyFlag, "sticky-variants", false, "Sert toujours la même variante à un visiteur (cookie)")
+	UpdateCmd.Flags().StringArrayVar(&updateRuleFlags, "rule", nil, "Remplace les règles de ciblage, au format os=ios,device=mobile,lang=fr,country=FR,url=https://... (répétable)")
+	UpdateCmd.Flags().BoolVar(&updateClearRules, "clear-rules", false, "Retire toutes les règles de ciblage du lien")
+	addDomainFlag(UpdateCmd)
+	UpdateCmd.MarkFlagRequired("code")
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	webhookURLFlag    string
+	webhookSecretFlag string
+	webhookEventsFlag string
+	webhookIDFlag     uint
+	webhookLimitFlag  int
+)
+
+// WebhookCmd regroupe les commandes de gestion des webhooks.
+var WebhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Gère les abonnements aux webhooks et les envois abandonnés.",
+	Long: `Un webhook reçoit en POST les événements auxquels il est abonné : link.created,
+link.updated, link.deleted, click.recorded et link.health_changed. Chaque envoi est signé
+(en-tête X-Webhook-Signature) et retenté en cas d'échec ; après le nombre maximal de
+tentatives (webhooks.max_attempts), il devient une lettre morte qui peut être renvoyée.
+
+Exemple:
+  url-shortener webhook add --url="https://crm.example.com/hooks" --events="link.created,click.recorded"
+  url-shortener webhook dead-letters
+  url-shortener webhook redeliver --id=12`,
+}
+
+// WebhookAddCmd représente la commande 'webhook add'
+var WebhookAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Abonne une URL à des événements.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWebhookService(func(webhooks *services.WebhookService) {
+			webhook, err := webhooks.CreateWebhook(webhookURLFlag, webhookSecretFlag, strings.Split(webhookEventsFlag, ","))
+			if err != nil {
+				exitOnWebhookError(err)
+				log.Fatalf("FATAL: Échec de la création du webhook: %v", err)
+			}
+			fmt.Printf("Webhook #%d créé pour %s.\n", webhook.ID, webhook.URL)
+			fmt.Printf("Événements: %s\n", webhook.Events)
+			fmt.Printf("Secret de signature: %s\n", webhook.Secret)
+		})
+	},
+}
+
+// WebhookListCmd représente la commande 'webhook list'
+var WebhookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les abonnements.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWebhookService(func(webhooks *services.WebhookService) {
+			list, err := webhooks.ListWebhooks()
+			if err != nil {
+				log.Fatalf("FATAL: Échec de la lecture des webhooks: %v", err)
+			}
+			if len(list) == 0 {
+				fmt.Println("Aucun webhook.")
+				return
+			}
+			for _, webhook := range list {
+				fmt.Printf("#%-5d %s\n       %s\n", webhook.ID, webhook.URL, webhook.Events)
+			}
+		})
+	},
+}
+
+// WebhookRemoveCmd représente la commande 'webhook remove'
+var WebhookRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Supprime un abonnement, ses envois en attente et ses lettres mortes.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWebhookService(func(webhooks *services.WebhookService) {
+			if err := webhooks.DeleteWebhook(webhookIDFlag); err != nil {
+				exitOnWebhookError(err)
+				log.Fatalf("FATAL: Échec de la suppression du webhook: %v", err)
+			}
+			fmt.Printf("Webhook #%d supprimé.\n", webhookIDFlag)
+		})
+	},
+}
+
+// WebhookDeadLettersCmd représente la commande 'webhook dead-letters'
+var WebhookDeadLettersCmd = &cobra.Command{
+	Use:   "dead-letters",
+	Short: "Liste les envois abandonnés, les plus récents en premier.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWebhookService(func(webhooks *services.WebhookService) {
+			dead, err := webhooks.ListDeadLetters(webhookIDFlag, webhookLimitFlag)
+			if err != nil {
+				log.Fatalf("FATAL: Échec de la lecture des lettres mortes: %v", err)
+			}
+			if len(dead) == 0 {
+				fmt.Println("Aucun envoi abandonné.")
+				return
+			}
+			for _, d := range dead {
+				fmt.Printf("#%-5d webhook #%-4d %-20s %s  %d tentative(s)  %s\n",
+					d.ID, d.WebhookID, d.Event, d.FailedAt.Local().Format("2006-01-02 15:04:05"), d.Attempts, d.LastError)
+			}
+		})
+	},
+}
+
+// WebhookRedeliverCmd représente la commande 'webhook redeliver'
+var WebhookRedeliverCmd = &cobra.Command{
+	Use:   "redeliver",
+	Short: "Remet un envoi abandonné dans la file ; il sera livré par le serveur.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWebhookService(func(webhooks *services.WebhookService) {
+			delivery, err := webhooks.Redeliver(webhookIDFlag)
+			if err != nil {
+				exitOnWebhookError(err)
+				log.Fatalf("FATAL: Échec du renvoi: %v", err)
+			}
+			fmt.Printf("Envoi %s (%s) remis en file pour le webhook #%d.\n", delivery.EventID, delivery.Event, delivery.WebhookID)
+		})
+	},
+}
+
+// withWebhookService ouvre la base et exécute une opération sur les webhooks.
+func withWebhookService(run func(webhooks *services.WebhookService)) {
+	cfg := cmd2.Cfg
+
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+	}
+
+	// Récupère la connexion SQL sous-jacente
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+	}
+
+	defer sqlDB.Close()
+
+	run(services.NewWebhookService(repository.NewWebhookRepository(db)))
+}
+
+// exitOnWebhookError affiche les erreurs dues à la saisie de l'utilisateur et arrête la commande.
+// Les autres erreurs sont laissées à l'appelant.
+func exitOnWebhookError(err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		fmt.Println("Erreur: webhook ou envoi introuvable.")
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvents),
+		errors.Is(err, services.ErrInvalidWebhookSecret):
+		fmt.Printf("Erreur: %v\n", err)
+	default:
+		return
+	}
+	os.Exit(1)
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(WebhookCmd)
+	WebhookCmd.AddCommand(WebhookAddCmd, WebhookListCmd, WebhookRemoveCmd, WebhookDeadLettersCmd, WebhookRedeliverCmd)
+
+	WebhookAddCmd.Flags().StringVar(&webhookURLFlag, "url", "", "URL appelée en POST pour chaque événement")
+	WebhookAddCmd.Flags().StringVar(&webhookEventsFlag, "events", "", "Événements séparés par des virgules")
+	WebhookAddCmd.Flags().StringVar(&webhookSecretFlag, "secret", "", "Secret de signature, généré s'il est absent")
+	WebhookAddCmd.MarkFlagRequired("url")
+	WebhookAddCmd.MarkFlagRequired("events")
+
+	WebhookRemoveCmd.Flags().UintVar(&webhookIDFlag, "id", 0, "Identifiant du webhook")
+	WebhookRemoveCmd.MarkFlagRequired("id")
+	WebhookRedeliverCmd.Flags().UintVar(&webhookIDFlag, "id", 0, "Identifiant de la lettre morte")
+	WebhookRedeliverCmd.MarkFlagRequired("id")
+	WebhookDeadLettersCmd.Flags().UintVar(&webhookIDFlag, "webhook", 0, "Restreint la liste à un webhook")
+	WebhookDeadLettersCmd.Flags().IntVar(&webhookLimitFlag, "limit", 50, "Nombre maximal d'envois affichés")
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	workspaceSlugFlag   string
+	workspaceNameFlag   string
+	workspaceOwnerFlag  string
+	workspaceMemberFlag string
+	workspaceRoleFlag   string
+	workspaceCodeFlag   string
+	workspaceDetachFlag bool
+)
+
+// WorkspaceCmd regroupe les commandes de gestion des espaces de travail.
+var WorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Gère les espaces de travail et leurs membres.",
+	Long: `Les liens créés au nom d'un espace de travail (en-tête X-Workspace de l'API) ne sont
+visibles et modifiables que par ses membres. Un membre est désigné par "key:<nom>" pour une clé
+d'API (auth.api_keys) ou par "user:<utilisateur>" pour un jeton JWT (auth.jwt) et tient l'un des
+rôles owner, editor ou viewer.
+
+Exemple:
+  url-shortener workspace create --slug="marketing" --name="Équipe marketing" --owner="key:alice"
+  url-shortener workspace set-member --slug="marketing" --member="user:bob" --role="editor"
+  url-shortener workspace members --slug="marketing"
+  url-shortener workspace assign-link --slug="marketing" --code="xyz123"
+
+Les liens créés hors de tout espace sont réservés aux administrateurs (consultables par tous si
+workspaces.shared_link_access vaut "view") : 'assign-link' les rattache à un espace.`,
+}
+
+// WorkspaceCreateCmd représente la commande 'workspace create'
+var WorkspaceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée un espace de travail.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaces *services.WorkspaceService) {
+			workspace, err := workspaces.CreateWorkspace(workspaceSlugFlag, workspaceNameFlag, workspaceOwnerFlag)
+			if err != nil {
+				exitOnWorkspaceError(err)
+				log.Fatalf("FATAL: Échec de la création de l'espace de travail: %v", err)
+			}
+			fmt.Printf("Espace de travail '%s' (%s) créé.\n", workspace.Slug, workspace.Name)
+			if workspaceOwnerFlag != "" {
+				fmt.Printf("Propriétaire: %s\n", workspaceOwnerFlag)
+			}
+		})
+	},
+}
+
+// WorkspaceListCmd représente la commande 'workspace list'
+var WorkspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les espaces de travail.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaces *services.WorkspaceService) {
+			list, err := workspaces.ListWorkspaces()
+			if err != nil {
+				log.Fatalf("FATAL: Échec de la lecture des espaces de travail: %v", err)
+			}
+			if len(list) == 0 {
+				fmt.Println("Aucun espace de travail.")
+				return
+			}
+			for _, workspace := range list {
+				fmt.Printf("%-20s %s\n", workspace.Slug, workspace.Name)
+			}
+		})
+	},
+}
+
+// WorkspaceMembersCmd représente la commande 'workspace members'
+var WorkspaceMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Liste les membres d'un espace de travail.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaces *services.WorkspaceService) {
+			workspace, members, err := workspaces.Members(workspaceSlugFlag)
+			if err != nil {
+				exitOnWorkspaceError(err)
+				log.Fatalf("FATAL: Échec de la lecture des membres: %v", err)
+			}
+			fmt.Printf("Membres de l'espace '%s' (%s):\n", workspace.Slug, workspace.Name)
+			if len(members) == 0 {
+				fmt.Println("  Aucun membre.")
+				return
+			}
+			for _, member := range members {
+				fmt.Printf("  %-30s %s\n", member.Member, member.Role)
+			}
+		})
+	},
+}
+
+// WorkspaceSetMemberCmd représente la commande 'workspace set-member'
+var WorkspaceSetMemberCmd = &cobra.Command{
+	Use:   "set-member",
+	Short: "Ajoute un membre à un espace de travail ou change son rôle.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaces *services.WorkspaceService) {
+			member, err := workspaces.SetMember(workspaceSlugFlag, workspaceMemberFlag, workspaceRoleFlag)
+			if err != nil {
+				exitOnWorkspaceError(err)
+				log.Fatalf("FATAL: Échec de l'enregistrement du membre: %v", err)
+			}
+			fmt.Printf("%s est %s de l'espace '%s'.\n", member.Member, member.Role, workspaceSlugFlag)
+		})
+	},
+}
+
+// WorkspaceRemoveMemberCmd représente la commande 'workspace remove-member'
+var WorkspaceRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member",
+	Short: "Retire un membre d'un espace de travail.",
+	Run: func(cmd *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaces *services.WorkspaceService) {
+			if err := workspaces.RemoveMember(workspaceSlugFlag, workspaceMemberFlag); err != nil {
+				exitOnWorkspaceError(err)
+				log.Fatalf("FATAL: Échec du retrait du membre: %v", err)
+			}
+			fmt.Printf("%s a été retiré de l'espace '%s'.\n", workspaceMemberFlag, workspaceSlugFlag)
+		})
+	},
+}
+
+// WorkspaceAssignLinkCmd représente la commande 'workspace assign-link'
+var WorkspaceAssignLinkCmd = &cobra.Command{
+	Use:   "assign-link",
+	Short: "Rattache un lien existant à un espace de travail, ou l'en détache.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if (workspaceSlugFlag == "") == !workspaceDetachFlag {
+			fmt.Println("Erreur: indiquez soit --slug, soit --detach.")
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		domain := resolveDomainFlag(cfg)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+		}
+
+		// Récupère la connexion SQL sous-jacente
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+		}
+
+		defer sqlDB.Close()
+
+		var workspaceID uint
+		if workspaceSlugFlag != "" {
+			workspace, err := services.NewWorkspaceService(repository.NewWorkspaceRepository(db)).GetWorkspace(workspaceSlugFlag)
+			if err != nil {
+				exitOnWorkspaceError(err)
+				log.Fatalf("FATAL: Échec de la lecture de l'espace de travail: %v", err)
+			}
+			workspaceID = workspace.ID
+		}
+
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: impossible d'initialiser la politique d'URLs: %v", err)
+		}
+		webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+		linkService := services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), urlPolicy, webhookService)
+
+		link, err := linkService.AssignWorkspace(services.CLIActor(), domain, workspaceCodeFlag, workspaceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", workspaceCodeFlag)
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec du rattachement du lien: %v", err)
+		}
+		if workspaceID == 0 {
+			fmt.Printf("Le lien %s n'appartient plus à aucun espace de travail.\n", link.Shortcode)
+			return
+		}
+		fmt.Printf("Le lien %s appartient désormais à l'espace '%s'.\n", link.Shortcode, workspaceSlugFlag)
+	},
+}
+
+// withWorkspaceService ouvre la base et exécute une opération sur les espaces de travail.
+func withWorkspaceService(run func(workspaces *services.WorkspaceService)) {
+	cfg := cmd2.Cfg
+
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("FATAL: impossible de se connecter à la base SQLite: %v", err)
+	}
+
+	// Récupère la connexion SQL sous-jacente
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: impossible d'obtenir la DB SQL sous-jacente: %v", err)
+	}
+
+	defer sqlDB.Close()
+
+	run(services.NewWorkspaceService(repository.NewWorkspaceRepository(db)))
+}
+
+// exitOnWorkspaceError affiche les erreurs dues à la saisie de l'utilisateur et arrête la commande.
+// Les autres erreurs sont laissées à l'appelant.
+func exitOnWorkspaceError(err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		fmt.Println("Erreur: espace de travail ou membre introuvable.")
+	case errors.Is(err, services.ErrInvalidWorkspace), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrInvalidMember), errors.Is(err, services.ErrWorkspaceExists),
+		errors.Is(err, services.ErrLastOwner):
+		fmt.Printf("Erreur: %v\n", err)
+	default:
+		return
+	}
+	os.Exit(1)
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(WorkspaceCmd)
+	WorkspaceCmd.AddCommand(WorkspaceCreateCmd, WorkspaceListCmd, WorkspaceMembersCmd, WorkspaceSetMemberCmd, WorkspaceRemoveMemberCmd, WorkspaceAssignLinkCmd)
+
+	for _, c := range []*cobra.Command{WorkspaceCreateCmd, WorkspaceMembersCmd, WorkspaceSetMemberCmd, WorkspaceRemoveMemberCmd} {
+		c.Flags().StringVar(&workspaceSlugFlag, "slug", "", "Identifiant de l'espace de travail")
+		c.MarkFlagRequired("slug")
+	}
+	WorkspaceCreateCmd.Flags().StringVar(&workspaceNameFlag, "name", "", "Nom de l'espace de travail, le slug par défaut")
+	WorkspaceCreateCmd.Flags().StringVar(&workspaceOwnerFlag, "owner", "", "Premier propriétaire : key:<nom de clé d'API> ou user:<utilisateur JWT>")
+	for _, c := range []*cobra.Command{WorkspaceSetMemberCmd, WorkspaceRemoveMemberCmd} {
+		c.Flags().StringVar(&workspaceMemberFlag, "member", "", "Membre : key:<nom de clé d'API> ou user:<utilisateur JWT>")
+		c.MarkFlagRequired("member")
+	}
+	WorkspaceSetMemberCmd.Flags().StringVar(&workspaceRoleFlag, "role", "", "Rôle du membre : owner, editor ou viewer")
+	WorkspaceSetMemberCmd.MarkFlagRequired("role")
+	WorkspaceAssignLinkCmd.Flags().StringVar(&workspaceSlugFlag, "slug", "", "Identifiant de l'espace de travail de destination")
+	WorkspaceAssignLinkCmd.Flags().BoolVar(&workspaceDetachFlag, "detach", false, "Détache le lien de tout espace de travail")
+	WorkspaceAssignLinkCmd.Flags().StringVar(&workspaceCodeFlag, "code", "", "Code court du lien")
+	WorkspaceAssignLinkCmd.MarkFlagRequired("code")
+	addDomainFlag(WorkspaceAssignLinkCmd)
+}
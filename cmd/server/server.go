@@ -13,10 +13,18 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/backup"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/geoip"
+	"github.com/axellelanca/urlshortener/internal/jwtauth"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/netutil"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/retention"
+	"github.com/axellelanca/urlshortener/internal/scheduler"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/webhooks"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/glebarez/sqlite"
 	"github.com/spf13/cobra"
@@ -44,7 +52,7 @@ var RunServerCmd = &cobra.Command{
 		}
 
 		// S'assurer que les tables requises existent avant de lancer les différents services
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		if err := database.Migrate(db); err != nil {
 			log.Fatalf("FATAL: impossible d'exécuter les migrations automatiques: %v", err)
 		}
 		log.Println("Base de données migrée.")
@@ -57,14 +65,70 @@ var RunServerCmd = &cobra.Command{
 		log.Println("Repositories initialisés.")
 
 		//  Initialiser les services métiers.
-		linkService := services.NewLinkService(linkRepo)
+		urlPolicy, err := services.NewURLPolicy(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'initialisation de la politique d'URLs: %v", err)
+		}
+		go urlPolicy.StartBlocklistReload(time.Duration(cfg.URLPolicy.BlocklistReloadSeconds) * time.Second)
+		auditRepo := repository.NewAuditRepository(db)
+		webhookRepo := repository.NewWebhookRepository(db)
+		webhookService := services.NewWebhookService(webhookRepo)
+		linkService := services.NewLinkService(linkRepo, auditRepo, urlPolicy, webhookService)
+		moderationService := services.NewModerationService(linkRepo, repository.NewReportRepository(db), auditRepo, webhookService)
+		auditService := services.NewAuditService(auditRepo)
+		scheduleService := services.NewScheduleService(linkRepo, repository.NewScheduleRepository(db), auditRepo, urlPolicy, webhookService)
+
+		// Base GeoIP optionnelle pour localiser les clics et cibler les redirections par pays.
+		locator, err := geoip.Open(cfg.GeoIP.DatabaseFile)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'ouverture de la base GeoIP: %v", err)
+		}
+		go locator.StartReload(time.Duration(cfg.GeoIP.ReloadSeconds) * time.Second)
+
+		// Réduction des IP des visiteurs avant leur enregistrement (RGPD).
+		anonymizer, err := netutil.NewIPAnonymizer(cfg.Analytics.IPMode, cfg.Analytics.IPHashSecret)
+		if err != nil {
+			log.Fatalf("FATAL: configuration analytics.ip_mode invalide: %v", err)
+		}
+		if anonymizer.Mode() == netutil.IPModeHashed && cfg.Analytics.IPHashSecret == "" {
+			log.Println("Attention: analytics.ip_hash_secret n'est pas défini, une clé aléatoire est utilisée.")
+		}
+
+		privacyService := services.NewPrivacyService(repository.NewPrivacyRepository(db), auditRepo, anonymizer)
+		workspaceService := services.NewWorkspaceService(repository.NewWorkspaceRepository(db))
+
+		// Authentification optionnelle des clients de l'API par jetons JWT d'un fournisseur d'identité.
+		jwtCfg := cfg.Auth.JWT
+		verifier, err := jwtauth.New(jwtauth.Options{
+			JWKSURL:     jwtCfg.JWKSURL,
+			JWKSFile:    jwtCfg.JWKSFile,
+			Issuer:      jwtCfg.Issuer,
+			Audience:    jwtCfg.Audience,
+			UserClaim:   jwtCfg.UserClaim,
+			AdminClaim:  jwtCfg.AdminClaim,
+			AdminValues: jwtCfg.AdminValues,
+			Leeway:      time.Duration(jwtCfg.LeewaySeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("FATAL: Échec du chargement des clés JWT (auth.jwt): %v", err)
+		}
+		go verifier.StartRefresh(time.Duration(jwtCfg.RefreshMinutes) * time.Minute)
 
 		// Laissez le log
 		log.Println("Services métiers initialisés.")
 
+		// Destinations des clics (base de données, fichier, sortie standard, service HTTP, webhooks)
+		clickSinks, err := workers.NewClickSinks(cfg, clickRepo, webhookService)
+		if err != nil {
+			log.Fatalf("FATAL: configuration click_sinks invalide: %v", err)
+		}
+		// Le hub du flux de clics en direct reçoit les clics comme les autres destinations.
+		clickHub := workers.NewClickHub(cfg.ClickStream.BufferSize, cfg.ClickStream.MaxSubscribers)
+		clickSinks = append(clickSinks, clickHub)
+
 		// Initialisation du channel ClickEventsChannel
 		clickEventsChannel := make(chan models.ClickEvent, cfg.Analytics.WorkerCount)
-		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo)
+		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickSinks, locator, anonymizer)
 
 		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
 			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
@@ -72,15 +136,73 @@ var RunServerCmd = &cobra.Command{
 		// Lancement du moniteur d'URLs.
 		// Utilisez l'intervalle configuré
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
+		monitorClient := netutil.NewSafeClient(netutil.SafeClientOptions{
+			Timeout:          time.Duration(cfg.Monitor.TimeoutSeconds) * time.Second,
+			MaxRedirects:     cfg.Monitor.MaxRedirects,
+			MaxResponseBytes: cfg.Monitor.MaxResponseBytes,
+			UserAgent:        cfg.Monitor.UserAgent,
+		})
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval, monitorClient, webhookService)
 
 		go urlMonitor.Start()
 		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
 
+		// Lancement du planificateur des changements de destination programmés.
+		schedulerInterval := time.Duration(cfg.Scheduler.IntervalSeconds) * time.Second
+		linkScheduler, err := scheduler.NewScheduler(scheduleService, schedulerInterval, cfg.Scheduler.BatchSize)
+		if err != nil {
+			log.Fatalf("FATAL: scheduler.interval_seconds doit être strictement positif: %v", err)
+		}
+		go linkScheduler.Start()
+
+		// Lancement de la livraison des webhooks, y compris les événements publiés par la CLI.
+		webhookCfg := cfg.Webhooks
+		webhookTimeout := time.Duration(webhookCfg.TimeoutSeconds) * time.Second
+		webhookClient := &http.Client{Timeout: webhookTimeout}
+		if !webhookCfg.AllowPrivateAddresses {
+			webhookClient = netutil.NewSafeClient(netutil.SafeClientOptions{
+				Timeout:          webhookTimeout,
+				MaxResponseBytes: 64 << 10,
+				UserAgent:        "urlshortener-webhooks/1.0",
+			})
+		}
+		dispatcher, err := webhooks.NewDispatcher(webhookService, webhookRepo, webhookClient, webhooks.Options{
+			Interval:    time.Duration(webhookCfg.IntervalSeconds) * time.Second,
+			BatchSize:   webhookCfg.BatchSize,
+			Workers:     webhookCfg.Workers,
+			MaxAttempts: webhookCfg.MaxAttempts,
+			BackoffBase: time.Duration(webhookCfg.BackoffBaseSeconds) * time.Second,
+			BackoffMax:  time.Duration(webhookCfg.BackoffMaxSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("FATAL: webhooks.interval_seconds, backoff_base_seconds et backoff_max_seconds doivent être strictement positifs: %v", err)
+		}
+		go dispatcher.Start()
+
+		// Lancement de l'agrégation des anciens clics si la rétention est activée.
+		if cfg.Retention.Enabled {
+			retentionInterval := time.Duration(cfg.Retention.IntervalMinutes) * time.Minute
+			roller, err := retention.NewRoller(clickRepo, cfg.Retention.RawDays, retentionInterval, cfg.Retention.BatchSize)
+			if err != nil {
+				log.Fatalf("FATAL: retention.raw_days et retention.interval_minutes doivent être strictement positifs: %v", err)
+			}
+			go roller.Start()
+		}
+
+		// Lancement des sauvegardes planifiées si elles sont activées.
+		if cfg.Backup.Enabled {
+			backupInterval := time.Duration(cfg.Backup.IntervalMinutes) * time.Minute
+			backupScheduler, err := backup.NewScheduler(db, cfg.Backup.Dir, backupInterval, cfg.Backup.Retention)
+			if err != nil {
+				log.Fatalf("FATAL: backup.interval_minutes doit être strictement positif: %v", err)
+			}
+			go backupScheduler.Start()
+		}
+
 		//  Configurer le routeur Gin et les handlers API.
 		// Passez les services nécessaires aux fonctions de configuration des routes.
 		router := gin.Default()
-		api.SetupRoutes(router, linkService, clickRepo, cfg)
+		api.SetupRoutes(router, linkService, moderationService, auditService, scheduleService, privacyService, workspaceService, webhookService, verifier, clickSinks, clickHub, locator, anonymizer, api.NewMemoryRateLimitStore(), cfg)
 
 		// Pas toucher au log
 		log.Println("Routes API configurées.")
@@ -112,6 +234,9 @@ var RunServerCmd = &cobra.Command{
 		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
 		time.Sleep(5 * time.Second)
 
+		// Écrit les clics encore en mémoire (envoi HTTP par lots) et ferme les fichiers.
+		workers.CloseSinks(clickSinks)
+
 		log.Println("Serveur arrêté proprement.")
 	},
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Nombre d'événements d'audit retournés par défaut et au maximum.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// ListAuditEventsHandler retourne le journal d'audit, filtré par les paramètres
+// ?domain=&short_code=&actor=&action=&source=&since=&until=&limit= (dates au format RFC 3339).
+// Un code court désigne le lien du domaine principal si ?domain= est absent, comme pour les
+// autres routes des liens ; sans code ni domaine, les événements de tous les domaines sont retournés.
+func ListAuditEventsHandler(auditService *services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := repository.AuditFilter{
+			ShortCode: c.Query("short_code"),
+			Actor:     c.Query("actor"),
+			Action:    c.Query("action"),
+			Source:    c.Query("source"),
+		}
+		if filter.ShortCode != "" || c.Query("domain") != "" {
+			domain := linkDomain(c)
+			filter.Domain = &domain
+		}
+
+		var err error
+		if filter.Since, err = parseTimeQuery(c, "since"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: since must be an RFC 3339 date"})
+			return
+		}
+		if filter.Until, err = parseTimeQuery(c, "until"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: until must be an RFC 3339 date"})
+			return
+		}
+
+		filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLimit)))
+		if err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: limit must be between 1 and 1000"})
+			return
+		}
+
+		events, err := auditService.ListEvents(filter)
+		if err != nil {
+			log.Printf("Error listing audit events: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(events))
+		for i := range events {
+			items = append(items, auditEventResponse(&events[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"events": items})
+	}
+}
+
+// auditEventResponse construit la représentation JSON d'un événement d'audit.
+// Les états avant/après sont renvoyés tels quels, en JSON.
+func auditEventResponse(event *models.AuditEvent) gin.H {
+	return gin.H{
+		"id":         event.ID,
+		"action":     event.Action,
+		"domain":     event.Domain,
+		"short_code": event.ShortCode,
+		"actor":      event.Actor,
+		"source":     event.Source,
+		"before":     rawJSON(event.Before),
+		"after":      rawJSON(event.After),
+		"created_at": event.CreatedAt,
+	}
+}
+
+// rawJSON insère un document JSON déjà sérialisé dans une réponse, ou null s'il est vide.
+func rawJSON(data string) interface{} {
+	if data == "" {
+		return nil
+	}
+	return json.RawMessage(data)
+}
+
+// parseTimeQuery lit un paramètre de requête au format RFC 3339, nil s'il est absent.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
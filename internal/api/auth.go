@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/jwtauth"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader est l'en-tête dans lequel les clients présentent leur clé d'API.
+const apiKeyHeader = "X-API-Key"
+
+// Clés du contexte Gin décrivant le client authentifié (principal) : son nom (nom de la clé
+// d'API ou utilisateur du jeton), la façon dont il s'est authentifié et ses droits d'administration.
+const (
+	principalNameContextKey  = "principal_name"
+	principalKindContextKey  = "principal_kind"
+	principalAdminContextKey = "principal_admin"
+)
+
+// anonymousActorContextKey est la clé du contexte Gin portant le nom enregistré comme auteur
+// des actions d'un client anonyme (voir AnonymousActorMiddleware).
+const anonymousActorContextKey = "anonymous_actor"
+
+// anonymousActor est l'auteur enregistré pour un client anonyme dont l'adresse IP n'est pas conservée.
+const anonymousActor = "anonymous"
+
+// Façons dont un client s'authentifie, reprises dans son identité ("key:marketing", "user:alice").
+const (
+	principalKindKey  = "key"
+	principalKindUser = "user"
+)
+
+// apiKey est l'empreinte d'une clé d'API configurée et ses droits.
+type apiKey struct {
+	name   string
+	digest [sha256.Size]byte
+	admin  bool
+}
+
+// APIKeyMiddleware identifie le client à partir de l'en-tête X-API-Key.
+// Une clé reconnue est enregistrée dans le contexte ; une clé inconnue est refusée
+// avec un 401. Les requêtes sans clé continuent en tant que clients anonymes.
+func APIKeyMiddleware(cfg *config.Config) gin.HandlerFunc {
+	// Les clés sont comparées via leur empreinte, en temps constant.
+	keys := make([]apiKey, 0, len(cfg.Auth.APIKeys))
+	for _, key := range cfg.Auth.APIKeys {
+		if key.Name != "" && key.Key != "" {
+			keys = append(keys, apiKey{name: key.Name, digest: sha256.Sum256([]byte(key.Key)), admin: key.Admin})
+		}
+	}
+
+	return func(c *gin.Context) {
+		presented := c.GetHeader(apiKeyHeader)
+		if presented == "" {
+			c.Next()
+			return
+		}
+
+		digest := sha256.Sum256([]byte(presented))
+		for _, key := range keys {
+			if subtle.ConstantTimeCompare(digest[:], key.digest[:]) == 1 {
+				setPrincipal(c, principalKindKey, key.name, key.admin)
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+	}
+}
+
+// BearerTokenMiddleware identifie le client à partir d'un jeton JWT présenté dans l'en-tête
+// Authorization: Bearer. Il doit être placé après APIKeyMiddleware : un client déjà identifié
+// par sa clé d'API n'est pas examiné. Un jeton invalide est refusé avec un 401 ; sans jeton,
+// ou si l'authentification par jeton n'est pas configurée (verifier nil), la requête continue.
+func BearerTokenMiddleware(verifier *jwtauth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(principalNameContextKey); ok || verifier == nil {
+			c.Next()
+			return
+		}
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			c.Next()
+			return
+		}
+
+		identity, err := verifier.Verify(strings.TrimSpace(token), time.Now())
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid bearer token"})
+			return
+		}
+		setPrincipal(c, principalKindUser, identity.User, identity.Admin)
+		c.Next()
+	}
+}
+
+// setPrincipal enregistre le client authentifié dans le contexte.
+func setPrincipal(c *gin.Context, kind, name string, admin bool) {
+	c.Set(principalNameContextKey, name)
+	c.Set(principalKindContextKey, kind)
+	c.Set(principalAdminContextKey, admin)
+}
+
+// AuthenticatedMiddleware réserve une route aux clients authentifiés par une clé d'API ou un
+// jeton : les clients anonymes reçoivent un 401. Il doit être placé après APIKeyMiddleware et
+// BearerTokenMiddleware.
+func AuthenticatedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(principalNameContextKey); !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminMiddleware réserve une route aux clients authentifiés par une clé d'administration
+// ou par un jeton donnant les droits d'administration.
+// Il doit être placé après APIKeyMiddleware et BearerTokenMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(principalNameContextKey); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+		if !c.GetBool(principalAdminContextKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API key required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AnonymousActorMiddleware prépare le nom sous lequel les actions d'un client anonyme sont
+// enregistrées dans le journal d'audit et publiées aux webhooks : son adresse IP, réduite selon
+// analytics.ip_mode comme celle des clics, ou "anonymous" si l'adresse n'est pas conservée.
+// Le journal d'audit étant en ajout seul, l'adresse complète n'y serait jamais effaçable.
+func AnonymousActorMiddleware(anonymizer *netutil.IPAnonymizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := anonymousActor
+		if ip := anonymizer.Anonymize(c.ClientIP()); ip != "" {
+			name = "ip:" + ip
+		}
+		c.Set(anonymousActorContextKey, name)
+		c.Next()
+	}
+}
+
+// requestActor identifie l'auteur d'une action faite via l'API : sa clé d'API ou l'utilisateur
+// de son jeton, ou le nom préparé par AnonymousActorMiddleware pour un client anonyme, avec
+// l'espace de travail au nom duquel il agit et ses droits.
+func requestActor(c *gin.Context) services.Actor {
+	name := anonymousActor
+	if _, ok := c.Get(principalNameContextKey); ok {
+		name = clientIdentity(c)
+	} else if anonymous := c.GetString(anonymousActorContextKey); anonymous != "" {
+		name = anonymous
+	}
+	actor := services.Actor{
+		Name:       name,
+		Source:     models.AuditSourceAPI,
+		Role:       c.GetString(workspaceRoleContextKey),
+		Admin:      c.GetBool(principalAdminContextKey),
+		SharedView: c.GetBool(sharedViewContextKey),
+	}
+	if workspace := requestWorkspace(c); workspace != nil {
+		actor.WorkspaceID = workspace.ID
+	}
+	return actor
+}
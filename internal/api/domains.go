@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// domainContextKey est la clé du contexte Gin sous laquelle est stocké le domaine court
+// de la requête. Le domaine principal est représenté par la chaîne vide.
+const domainContextKey = "link_domain"
+
+// DomainQueryMiddleware lit le domaine des liens visés par une requête d'API dans le
+// paramètre ?domain=. Sans paramètre, la requête porte sur le domaine principal ;
+// un domaine non configuré est refusé en 400.
+func DomainQueryMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		domain, ok := cfg.LookupDomain(c.Query("domain"))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request: unknown domain"})
+			return
+		}
+		c.Set(domainContextKey, domain)
+		c.Next()
+	}
+}
+
+// HostDomainMiddleware détermine le domaine court d'une requête de redirection à partir de
+// son en-tête Host. Un hôte inconnu (adresse IP, nom interne...) est servi par le domaine principal.
+func HostDomainMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		domain, _ := cfg.LookupDomain(c.Request.Host)
+		c.Set(domainContextKey, domain)
+		c.Next()
+	}
+}
+
+// linkDomain retourne le domaine court déterminé pour la requête par l'un des middlewares de domaine.
+func linkDomain(c *gin.Context) string {
+	return c.GetString(domainContextKey)
+}
+
+// secureCookies indique si les cookies posés pour un lien doivent porter l'attribut Secure,
+// c'est-à-dire si le domaine du lien est servi en HTTPS.
+func secureCookies(cfg *config.Config, link *models.Link) bool {
+	return strings.HasPrefix(cfg.BaseURLFor(link.Domain), "https://")
+}
@@ -2,25 +2,38 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/geoip"
+	"github.com/axellelanca/urlshortener/internal/jwtauth"
 	"github.com/axellelanca/urlshortener/internal/models"
-	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/netutil"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/signing"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm" // Pour gérer gorm.ErrRecordNotFound
 )
 
+// reservedQueryParams sont les paramètres propres au service (marqueurs d'analytics),
+// jamais transmis à l'URL longue lorsque le lien transmet les paramètres de requête.
+var reservedQueryParams = map[string]bool{
+	"src": true,
+	"exp": true,
+	"sig": true,
+}
+
 // ClickEventsChannel est le channel global (ou injecté) utilisé pour envoyer les événements de clic
 // aux workers asynchrones. Il est bufferisé pour ne pas bloquer les requêtes de redirection.
 var ClickEventsChannel chan models.ClickEvent
 
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickRepo repository.ClickRepository, cfg *config.Config) {
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, moderation *services.ModerationService, auditService *services.AuditService, scheduleService *services.ScheduleService, privacy *services.PrivacyService, workspaces *services.WorkspaceService, webhooks *services.WebhookService, verifier *jwtauth.Verifier, clickSinks []workers.ClickSink, clickHub *workers.ClickHub, locator *geoip.Locator, anonymizer *netutil.IPAnonymizer, rateLimitStore RateLimitStore, cfg *config.Config) {
 	bufferSize := cfg.Analytics.BufferSize
 	workerCount := cfg.Analytics.WorkerCount
 
@@ -31,22 +44,113 @@ func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickRep
 	}
 
 	// Lancer les workers
-	workers.StartClickWorkers(workerCount, ClickEventsChannel, clickRepo)
+	workers.StartClickWorkers(workerCount, ClickEventsChannel, clickSinks, locator, anonymizer)
 
 	log.Printf("ClickEventsChannel initialisé (buffer=%d) avec %d worker(s)", bufferSize, workerCount)
 
+	// Clés de signature des liens signés, avec rotation
+	signer, err := signing.NewSigner(cfg.SigningKeys(), cfg.Signing.ActiveKeyID)
+	if err != nil {
+		log.Fatalf("FATAL: configuration de signature invalide: %v", err)
+	}
+
+	// IP du client : les en-têtes de transfert ne sont crus que s'ils viennent d'un proxy de confiance.
+	router.ForwardedByClientIP = len(cfg.Server.TrustedProxies) > 0
+	router.RemoteIPHeaders = cfg.Server.ForwardedHeaders
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("FATAL: configuration server.trusted_proxies invalide: %v", err)
+	}
+
+	switch cfg.Workspaces.SharedLinkAccess {
+	case "none", "view":
+	default:
+		log.Fatalf("FATAL: workspaces.shared_link_access doit valoir \"none\" ou \"view\" (valeur: %q)", cfg.Workspaces.SharedLinkAccess)
+	}
+
+	// Limites de débit par catégorie de routes
+	createLimit := rateLimit(rateLimitStore, "create", cfg.RateLimit.Create, cfg)
+	redirectLimit := rateLimit(rateLimitStore, "redirect", cfg.RateLimit.Redirect, cfg)
+	statsLimit := rateLimit(rateLimitStore, "stats", cfg.RateLimit.Stats, cfg)
+
+	// Intervalle des commentaires gardant ouverts les flux de clics en direct
+	heartbeat := time.Duration(cfg.ClickStream.HeartbeatSeconds) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+
 	// Route de Health Check
 	router.GET("/health", HealthCheckHandler())
 
 	// Routes de l'API v1
 	v1 := router.Group("/api/v1")
+	v1.Use(APIKeyMiddleware(cfg), BearerTokenMiddleware(verifier), AnonymousActorMiddleware(anonymizer), WorkspaceMiddleware(workspaces, cfg), DomainQueryMiddleware(cfg))
 	{
-		v1.POST("/links", CreateShortLinkHandler(linkService, cfg))
-		v1.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		// Les modifications d'un lien existant exigent un client authentifié.
+		authenticated := AuthenticatedMiddleware()
+
+		v1.POST("/links", createLimit, CreateShortLinkHandler(linkService, signer, cfg))
+		v1.PATCH("/links/:shortCode", authenticated, createLimit, UpdateLinkHandler(linkService, signer, cfg))
+		v1.DELETE("/links/:shortCode", authenticated, createLimit, DeleteLinkHandler(linkService))
+		v1.GET("/links/:shortCode/versions", statsLimit, GetLinkVersionsHandler(linkService))
+		v1.POST("/links/:shortCode/rollback", authenticated, createLimit, RollbackLinkHandler(linkService, cfg))
+		v1.GET("/links/:shortCode/schedule", statsLimit, ListScheduledChangesHandler(scheduleService))
+		v1.POST("/links/:shortCode/schedule", authenticated, createLimit, ScheduleChangeHandler(scheduleService))
+		v1.DELETE("/links/:shortCode/schedule/:id", authenticated, createLimit, CancelScheduledChangeHandler(scheduleService))
+		v1.POST("/links/:shortCode/sign", authenticated, createLimit, SignLinkHandler(linkService, signer, cfg))
+		v1.GET("/links/:shortCode/stats", statsLimit, GetLinkStatsHandler(linkService))
+		v1.GET("/links/:shortCode/qr", statsLimit, GetLinkQRCodeHandler(linkService, cfg))
+		v1.GET("/links/:shortCode/clicks/stream", statsLimit, LinkClickStreamHandler(linkService, clickHub, heartbeat))
+		v1.POST("/links/:shortCode/report", createLimit, ReportLinkHandler(moderation, anonymizer))
+
+		// Membres de l'espace de travail désigné par X-Workspace, gérés par ses propriétaires
+		v1.GET("/workspace/members", statsLimit, requireWorkspace(services.PermissionView), ListMembersHandler(workspaces))
+		v1.PUT("/workspace/members/:member", createLimit, requireWorkspace(services.PermissionManage), SetMemberHandler(workspaces))
+		v1.DELETE("/workspace/members/:member", createLimit, requireWorkspace(services.PermissionManage), RemoveMemberHandler(workspaces))
+		v1.GET("/workspace/clicks/stream", statsLimit, requireWorkspace(services.PermissionView), WorkspaceClickStreamHandler(clickHub, heartbeat))
+
+		// Routes de modération, réservées aux clés d'administration
+		admin := v1.Group("/admin", AdminMiddleware())
+		admin.GET("/reports", ListReportsHandler(moderation))
+		admin.POST("/reports/:id/resolve", ResolveReportHandler(moderation))
+		admin.PUT("/links/:shortCode/status", SetLinkStatusHandler(moderation, cfg))
+		admin.GET("/links/:shortCode/status", LinkStatusHistoryHandler(moderation))
+
+		// Protection des données personnelles : effacement, purge et anonymisation
+		admin.POST("/privacy/erase", EraseVisitorHandler(privacy))
+		admin.POST("/privacy/anonymize", AnonymizeClicksHandler(privacy, cfg))
+		admin.POST("/links/:shortCode/purge", PurgeLinkHandler(privacy))
+
+		// Abonnements aux webhooks et renvoi des envois abandonnés
+		admin.POST("/webhooks", CreateWebhookHandler(webhooks))
+		admin.GET("/webhooks", ListWebhooksHandler(webhooks))
+		admin.DELETE("/webhooks/:id", DeleteWebhookHandler(webhooks))
+		admin.GET("/webhooks/dead-letters", ListDeadLettersHandler(webhooks))
+		admin.POST("/webhooks/dead-letters/:id/redeliver", RedeliverHandler(webhooks))
+
+		// Journal d'audit des modifications de liens, réservé aux clés d'administration
+		v1.GET("/audit", AdminMiddleware(), statsLimit, ListAuditEventsHandler(auditService))
 	}
 
 	// Route de Redirection (au niveau racine pour les short codes)
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	// Le POST reçoit le formulaire de mot de passe des liens protégés.
+	gate := newPasswordGate(cfg)
+	// Le domaine du lien est déterminé par l'en-tête Host de la requête.
+	hostDomain := HostDomainMiddleware(cfg)
+	router.GET("/:shortCode", redirectLimit, hostDomain, RedirectHandler(linkService, scheduleService, locator, cfg, gate, signer))
+	router.POST("/:shortCode", redirectLimit, hostDomain, PasswordSubmitHandler(linkService, gate))
+}
+
+// rateLimit construit le middleware de limitation de débit d'une catégorie de routes,
+// ou un middleware neutre si la limitation est désactivée.
+func rateLimit(store RateLimitStore, scope string, limit config.RateLimitConfig, cfg *config.Config) gin.HandlerFunc {
+	if !cfg.RateLimit.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return RateLimitMiddleware(store, scope, RateLimit{
+		Requests: limit.Requests,
+		Per:      time.Duration(limit.PerSeconds) * time.Second,
+		Burst:    limit.Burst,
+	})
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
@@ -58,11 +162,54 @@ func HealthCheckHandler() gin.HandlerFunc {
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	LongURL         string     `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	Domain          string     `json:"domain"`                          // Domaine court du lien, vide pour le domaine principal
+	RedirectType    int        `json:"redirect_type"`                   // 301, 302, 307 ou 308 ; vide pour la valeur par défaut du serveur
+	ExpiresAt       *time.Time `json:"expires_at"`                      // Date d'expiration optionnelle (RFC 3339)
+	ActiveFrom      *time.Time `json:"active_from"`                     // Date de mise en ligne optionnelle (RFC 3339)
+	ActiveUntil     *time.Time `json:"active_until"`                    // Synonyme de expires_at : fin de la fenêtre d'activation
+	TrackEveryClick bool       `json:"track_every_click"`               // Interdit la mise en cache de la redirection
+	ForwardQuery    bool       `json:"forward_query"`                   // Transmet les paramètres de l'URL courte à l'URL longue
+	Password        string     `json:"password"`                        // Mot de passe optionnel protégeant le lien
+	SignedOnly      bool       `json:"signed_only"`                     // N'accepte que les URLs signées (voir /sign)
+
+	// Destinations pondérées d'un test A/B, et mémorisation de la variante servie par visiteur
+	Variants       []services.Variant `json:"variants"`
+	StickyVariants bool               `json:"sticky_variants"`
+
+	// Règles de ciblage (os, device, language, country) évaluées par priorité croissante
+	Rules []services.Rule `json:"rules"`
+
+	// Paramètres de campagne ajoutés à l'URL longue avant sa création
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMTerm     string `json:"utm_term"`
+	UTMContent  string `json:"utm_content"`
+}
+
+// UpdateLinkRequest représente le corps de la requête JSON pour la modification d'un lien.
+// Les champs absents ne sont pas modifiés.
+type UpdateLinkRequest struct {
+	LongURL         *string    `json:"long_url" binding:"omitempty,url"`
+	RedirectType    *int       `json:"redirect_type"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ActiveFrom      *time.Time `json:"active_from"`
+	ActiveUntil     *time.Time `json:"active_until"`      // Synonyme de expires_at
+	ClearExpiresAt  bool       `json:"clear_expires_at"`  // Rend le lien permanent (retire expires_at/active_until)
+	ClearActiveFrom bool       `json:"clear_active_from"` // Retire la date de mise en ligne
+	TrackEveryClick *bool      `json:"track_every_click"`
+	ForwardQuery    *bool      `json:"forward_query"`
+	Password        *string    `json:"password"` // Chaîne vide pour retirer la protection
+	SignedOnly      *bool      `json:"signed_only"`
+
+	Variants       *[]services.Variant `json:"variants"` // Remplace les variantes, [] pour les retirer
+	StickyVariants *bool               `json:"sticky_variants"`
+	Rules          *[]services.Rule    `json:"rules"` // Remplace les règles de ciblage, [] pour les retirer
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
-func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+func CreateShortLinkHandler(linkService *services.LinkService, signer *signing.Signer, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
 
@@ -72,32 +219,199 @@ func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Confi
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
 			return
 		}
+		if req.SignedOnly && !signer.Enabled() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Link signing is not configured"})
+			return
+		}
+		expiresAt, ok := activeUntil(c, req.ExpiresAt, req.ActiveUntil)
+		if !ok {
+			return
+		}
+		// Le domaine du corps de la requête l'emporte sur le paramètre ?domain=.
+		domain := linkDomain(c)
+		if req.Domain != "" {
+			if domain, ok = cfg.LookupDomain(req.Domain); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: unknown domain"})
+				return
+			}
+		}
 
 		// Appeler le LinkService pour créer le nouveau lien
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(requestActor(c), req.LongURL, services.LinkOptions{
+			Domain:          domain,
+			RedirectType:    req.RedirectType,
+			ExpiresAt:       expiresAt,
+			ActiveFrom:      req.ActiveFrom,
+			TrackEveryClick: req.TrackEveryClick,
+			ForwardQuery:    req.ForwardQuery,
+			Password:        req.Password,
+			SignedOnly:      req.SignedOnly,
+			Variants:        req.Variants,
+			StickyVariants:  req.StickyVariants,
+			Rules:           req.Rules,
+			UTM: services.UTMParams{
+				Source:   req.UTMSource,
+				Medium:   req.UTMMedium,
+				Campaign: req.UTMCampaign,
+				Term:     req.UTMTerm,
+				Content:  req.UTMContent,
+			},
+		})
 		if err != nil {
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			if isValidationError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			log.Printf("Error creating link: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
 			return
 		}
 
 		// Retourne le code court et l'URL longue dans la réponse JSON.
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.Shortcode,
-			"long_url":       link.LongURL,
-			"full_short_url": cfg.Server.BaseURL + "/" + link.Shortcode,
+		c.JSON(http.StatusCreated, linkResponse(link, cfg))
+	}
+}
+
+// UpdateLinkHandler gère la modification partielle d'un lien existant.
+func UpdateLinkHandler(linkService *services.LinkService, signer *signing.Signer, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if req.SignedOnly != nil && *req.SignedOnly && !signer.Enabled() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Link signing is not configured"})
+			return
+		}
+		expiresAt, ok := activeUntil(c, req.ExpiresAt, req.ActiveUntil)
+		if !ok {
+			return
+		}
+
+		link, err := linkService.UpdateLink(requestActor(c), linkDomain(c), shortCode, services.LinkUpdate{
+			LongURL:         req.LongURL,
+			RedirectType:    req.RedirectType,
+			ExpiresAt:       expiresAt,
+			ActiveFrom:      req.ActiveFrom,
+			ClearExpiresAt:  req.ClearExpiresAt,
+			ClearActiveFrom: req.ClearActiveFrom,
+			TrackEveryClick: req.TrackEveryClick,
+			ForwardQuery:    req.ForwardQuery,
+			Password:        req.Password,
+			SignedOnly:      req.SignedOnly,
+			Variants:        req.Variants,
+			StickyVariants:  req.StickyVariants,
+			Rules:           req.Rules,
 		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			if isValidationError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error updating link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link, cfg))
+	}
+}
+
+// DeleteLinkHandler supprime un lien. Le code court n'est jamais réattribué.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(requestActor(c), linkDomain(c), shortCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error deleting link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// activeUntil fusionne expires_at et son synonyme active_until. Les deux champs ne peuvent
+// être fournis ensemble ; en cas de conflit, un 400 est envoyé et ok vaut false.
+func activeUntil(c *gin.Context, expiresAt, activeUntil *time.Time) (result *time.Time, ok bool) {
+	if activeUntil == nil {
+		return expiresAt, true
 	}
+	if expiresAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: expires_at and active_until cannot both be set"})
+		return nil, false
+	}
+	return activeUntil, true
+}
+
+// linkResponse construit la représentation JSON d'un lien retournée par l'API.
+func linkResponse(link *models.Link, cfg *config.Config) gin.H {
+	return gin.H{
+		"domain":             link.Domain,
+		"short_code":         link.Shortcode,
+		"long_url":           link.LongURL,
+		"full_short_url":     cfg.ShortURL(link.Domain, link.Shortcode),
+		"redirect_type":      redirectStatus(link, cfg),
+		"expires_at":         link.ExpiresAt,
+		"active_from":        link.ActiveFrom,
+		"next_change_at":     link.NextChangeAt,
+		"track_every_click":  link.TrackEveryClick,
+		"forward_query":      link.ForwardQuery,
+		"password_protected": link.IsProtected(),
+		"signed_only":        link.SignedOnly,
+		"status":             linkStatus(link),
+		"version":            link.Version,
+		"variants":           variantResponse(link),
+		"sticky_variants":    link.StickyVariants,
+		"rules":              ruleResponse(link),
+	}
+}
+
+// isValidationError indique si err est une erreur de validation métier à renvoyer en 400.
+func isValidationError(err error) bool {
+	return errors.Is(err, services.ErrInvalidRedirectType) ||
+		errors.Is(err, services.ErrExpiryInPast) ||
+		errors.Is(err, services.ErrInvalidActiveWindow) ||
+		errors.Is(err, services.ErrConflictingClear) ||
+		errors.Is(err, services.ErrPasswordTooShort) ||
+		errors.Is(err, services.ErrPasswordTooLong) ||
+		errors.Is(err, services.ErrURLRejected) ||
+		errors.Is(err, services.ErrInvalidVariants) ||
+		errors.Is(err, services.ErrInvalidRules)
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+func RedirectHandler(linkService *services.LinkService, scheduleService *services.ScheduleService, locator *geoip.Locator, cfg *config.Config, gate *passwordGate, signer *signing.Signer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
 		// Récupérer l'URL longue associée au shortCode depuis le linkService
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.GetLinkByShortCode(linkDomain(c), shortCode)
 		if err != nil {
 			// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -110,12 +424,72 @@ func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
+		// Un lien désactivé ou bloqué par la modération affiche une page d'information.
+		if !link.IsActive() {
+			renderUnavailable(c, link)
+			return
+		}
+
+		// Un changement de destination programmé et échu est appliqué sans attendre le planificateur.
+		now := time.Now()
+		if link.HasDueChange(now) {
+			if link, err = scheduleService.RefreshLink(link, now); err != nil {
+				log.Printf("Error applying scheduled changes for %s: %v", shortCode, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+		}
+
+		// Un lien dont la date de mise en ligne n'est pas atteinte affiche une page d'attente.
+		if link.IsNotYetActive(now) {
+			renderNotYetActive(c, link)
+			return
+		}
+
+		// Un lien expiré n'est plus servi. La réponse ne doit pas être mise en cache.
+		if link.IsExpired(now) {
+			c.Header("Cache-Control", "no-store")
+			c.JSON(http.StatusGone, gin.H{"error": "Lien expiré"})
+			return
+		}
+
+		// Un lien signé n'est servi qu'avec une signature valide et non expirée. Sans signature
+		// valide, on répond comme pour un lien inexistant afin de ne pas permettre l'énumération.
+		if link.SignedOnly {
+			if err := signer.Verify(services.SigningSubject(link), c.Query("exp"), c.Query("sig"), time.Now()); err != nil {
+				c.Header("Cache-Control", "no-store")
+				if errors.Is(err, signing.ErrExpired) {
+					c.JSON(http.StatusGone, gin.H{"error": "Lien expiré"})
+					return
+				}
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+		}
+
+		// Un lien protégé affiche le formulaire de mot de passe tant que le visiteur
+		// n'a pas de cookie d'accès valide. Aucun clic n'est enregistré dans ce cas.
+		if link.IsProtected() && !gate.hasAccess(c, link) {
+			renderPasswordForm(c, http.StatusOK, "")
+			return
+		}
+
+		// La destination dépend des règles de ciblage du lien, puis de ses variantes A/B.
+		destination, variantName := chooseDestination(c, link, locator, cfg)
+
 		// Créer un ClickEvent avec les informations pertinentes.
 		clickEvent := models.ClickEvent{
-			LinkID:    link.ID,
-			Timestamp: time.Now(),
-			UserAgent: c.Request.UserAgent(),
-			IpAddress: c.ClientIP(),
+			LinkID:      link.ID,
+			Domain:      link.Domain,
+			ShortCode:   link.Shortcode,
+			WorkspaceID: link.WorkspaceID,
+			Timestamp:   time.Now(),
+			UserAgent:   c.Request.UserAgent(),
+			IpAddress:   c.ClientIP(),
+			Referrer:    referrerHost(c.Request.Referer()),
+			Source:      clickSource(c.Query("src")),
+			Version:     link.Version,
+			Variant:     variantName,
 		}
 
 		// Envoyer le ClickEvent dans le ClickEventsChannel avec le Multiplexage.
@@ -127,11 +501,55 @@ func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
 			log.Printf("Warning: ClickEventsChannel plein, clic perdu pour %s", shortCode)
 		}
 
-		// Effectuer la redirection HTTP 302 (StatusFound) vers l'URL longue.
-		c.Redirect(http.StatusFound, link.LongURL)
+		// Transmettre les paramètres de l'URL courte à l'URL longue si le lien l'autorise.
+		if link.ForwardQuery {
+			destination = services.MergeQuery(destination, c.Request.URL.Query(), reservedQueryParams)
+		}
+
+		// Effectuer la redirection HTTP vers l'URL longue avec le code propre au lien.
+		setRedirectCacheHeaders(c, link, cfg)
+		c.Redirect(redirectStatus(link, cfg), destination)
 	}
 }
 
+// redirectStatus retourne le code HTTP de redirection d'un lien, ou celui par défaut du serveur.
+func redirectStatus(link *models.Link, cfg *config.Config) int {
+	if link.RedirectType != 0 {
+		return link.RedirectType
+	}
+	if services.IsValidRedirectType(cfg.Server.DefaultRedirectType) {
+		return cfg.Server.DefaultRedirectType
+	}
+	return http.StatusFound
+}
+
+// setRedirectCacheHeaders définit la politique de cache d'une redirection.
+// Seules les redirections permanentes (301/308) sans fenêtre d'activation ni changement
+// programmé, sans variantes A/B ni règles de ciblage, sans mot de passe, hors mode signé et
+// sans suivi strict des clics peuvent être mises en cache par les navigateurs : un navigateur
+// qui réutilise une redirection en cache ne repasse pas par le serveur et le clic n'est pas compté.
+func setRedirectCacheHeaders(c *gin.Context, link *models.Link, cfg *config.Config) {
+	status := redirectStatus(link, cfg)
+	permanent := status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+
+	if permanent && isCacheable(link) && cfg.Server.RedirectCacheMaxAge > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", cfg.Server.RedirectCacheMaxAge))
+		return
+	}
+	c.Header("Cache-Control", "private, no-cache, no-store, max-age=0")
+}
+
+// isCacheable indique si la redirection d'un lien peut être réutilisée par un navigateur
+// sans repasser par le serveur.
+func isCacheable(link *models.Link) bool {
+	switch {
+	case link.ExpiresAt != nil, link.ActiveFrom != nil, link.NextChangeAt != nil,
+		link.TrackEveryClick, link.IsProtected(), link.SignedOnly, link.HasVariants(), link.HasRules():
+		return false
+	}
+	return true
+}
+
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
 func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -139,24 +557,114 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 		shortCode := c.Param("shortCode")
 
 		// Appeler le LinkService pour obtenir le lien et le nombre total de clics
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		link, totalClicks, err := linkService.GetLinkStats(requestActor(c), linkDomain(c), shortCode)
 		if err != nil {
 			// Gérer le cas où le lien n'est pas trouvé
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
 				return
 			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
 			// Gérer d'autres erreurs
 			log.Printf("Error retrieving stats for %s: %v", shortCode, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
+		// Récupérer la répartition des clics par jour (clics bruts et agrégats confondus)
+		clicksByDay, err := linkService.GetDailyClicks(link)
+		if err != nil {
+			log.Printf("Error retrieving daily clicks for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par canal d'origine (ex: scans de QR code)
+		clicksBySource, err := linkService.GetClicksBySource(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by source for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par version de destination
+		clicksByVersion, err := linkService.GetClicksByVersion(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by version for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par variante A/B
+		clicksByVariant, err := linkService.GetClicksByVariant(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by variant for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par pays (vide sans base GeoIP)
+		clicksByCountry, err := linkService.GetClicksByCountry(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by country for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par domaine référent
+		clicksByReferrer, err := linkService.GetClicksByReferrer(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by referrer for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Récupérer la répartition des clics par type d'appareil
+		clicksByDevice, err := linkService.GetClicksByDevice(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by device for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
 		// Retourne les statistiques dans la réponse JSON.
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.Shortcode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"domain":             link.Domain,
+			"short_code":         link.Shortcode,
+			"long_url":           link.LongURL,
+			"total_clicks":       totalClicks,
+			"clicks_by_day":      clicksByDay,
+			"clicks_by_source":   clicksBySource,
+			"clicks_by_version":  clicksByVersion,
+			"clicks_by_variant":  clicksByVariant,
+			"clicks_by_country":  clicksByCountry,
+			"clicks_by_referrer": clicksByReferrer,
+			"clicks_by_device":   clicksByDevice,
 		})
 	}
 }
+
+// clickSource retourne la source à enregistrer pour la valeur du marqueur ?src=,
+// ou une chaîne vide si elle ne fait pas partie des sources connues.
+func clickSource(src string) string {
+	if services.KnownSources[src] {
+		return src
+	}
+	return ""
+}
+
+// referrerHost réduit un en-tête Referer à son nom d'hôte, pour limiter la cardinalité
+// des statistiques et ne pas conserver de chemin ou de paramètres potentiellement personnels.
+func referrerHost(referer string) string {
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Nombre de signalements retournés par défaut et au maximum par la file de modération.
+const (
+	defaultReportsLimit = 50
+	maxReportsLimit     = 500
+)
+
+// ReportLinkRequest représente le corps de la requête JSON d'un signalement d'abus.
+type ReportLinkRequest struct {
+	Reason  string `json:"reason" binding:"required"` // spam, phishing, malware, illegal ou other
+	Details string `json:"details"`
+}
+
+// ResolveReportRequest représente la décision d'un administrateur sur un signalement.
+type ResolveReportRequest struct {
+	Action string `json:"action" binding:"required"` // dismiss, disable ou block
+	Note   string `json:"note"`
+}
+
+// SetLinkStatusRequest représente le changement d'état d'un lien par un administrateur.
+type SetLinkStatusRequest struct {
+	Status string `json:"status" binding:"required"` // active, disabled ou blocked
+	Reason string `json:"reason"`
+}
+
+// ReportLinkHandler enregistre le signalement d'abus d'un visiteur sur un lien.
+// L'IP du visiteur est conservée selon le mode analytics.ip_mode, comme celle des clics.
+func ReportLinkHandler(moderation *services.ModerationService, anonymizer *netutil.IPAnonymizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req ReportLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		report, err := moderation.ReportLink(linkDomain(c), shortCode, req.Reason, req.Details, anonymizer.Anonymize(c.ClientIP()))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidReportReason) || errors.Is(err, services.ErrReportDetailsTooLong) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error reporting link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"id": report.ID, "status": report.Status})
+	}
+}
+
+// ListReportsHandler retourne la file des signalements (?status=open|resolved|dismissed&limit=50).
+func ListReportsHandler(moderation *services.ModerationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.ReportStatusOpen)
+		switch status {
+		case models.ReportStatusOpen, models.ReportStatusResolved, models.ReportStatusDismissed:
+		case "all":
+			status = ""
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: status must be open, resolved, dismissed or all"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReportsLimit)))
+		if err != nil || limit < 1 || limit > maxReportsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: limit must be between 1 and 500"})
+			return
+		}
+
+		reports, err := moderation.ListReports(status, limit)
+		if err != nil {
+			log.Printf("Error listing reports: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(reports))
+		for i := range reports {
+			items = append(items, reportResponse(&reports[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"reports": items})
+	}
+}
+
+// ResolveReportHandler applique la décision d'un administrateur à un signalement ouvert.
+func ResolveReportHandler(moderation *services.ModerationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Signalement non trouvé"})
+			return
+		}
+
+		var req ResolveReportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		report, err := moderation.ResolveReport(uint(id), req.Action, requestActor(c), req.Note)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Signalement non trouvé"})
+			case errors.Is(err, services.ErrInvalidReportAction):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrReportAlreadyResolved):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Error resolving report %d: %v", id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, reportResponse(report))
+	}
+}
+
+// SetLinkStatusHandler change l'état d'un lien (active, disabled ou blocked).
+func SetLinkStatusHandler(moderation *services.ModerationService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req SetLinkStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		link, err := moderation.SetLinkStatus(linkDomain(c), shortCode, req.Status, requestActor(c), req.Reason)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidLinkStatus) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error changing status of link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link, cfg))
+	}
+}
+
+// LinkStatusHistoryHandler retourne l'historique des changements d'état d'un lien.
+func LinkStatusHistoryHandler(moderation *services.ModerationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, changes, err := moderation.StatusHistory(linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			log.Printf("Error retrieving status history for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(changes))
+		for _, change := range changes {
+			items = append(items, gin.H{
+				"from_status": change.FromStatus,
+				"to_status":   change.ToStatus,
+				"actor":       change.Actor,
+				"reason":      change.Reason,
+				"created_at":  change.CreatedAt,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.Shortcode,
+			"status":     linkStatus(link),
+			"history":    items,
+		})
+	}
+}
+
+// reportResponse construit la représentation JSON d'un signalement.
+func reportResponse(report *models.AbuseReport) gin.H {
+	return gin.H{
+		"id":          report.ID,
+		"short_code":  report.Link.Shortcode,
+		"long_url":    report.Link.LongURL,
+		"link_status": linkStatus(&report.Link),
+		"reason":      report.Reason,
+		"details":     report.Details,
+		"reporter_ip": report.ReporterIP,
+		"status":      report.Status,
+		"resolution":  report.Resolution,
+		"resolved_by": report.ResolvedBy,
+		"resolved_at": report.ResolvedAt,
+		"created_at":  report.CreatedAt,
+	}
+}
+
+// linkStatus retourne l'état d'un lien, les liens antérieurs aux états étant actifs.
+func linkStatus(link *models.Link) string {
+	if link.Status == "" {
+		return models.LinkStatusActive
+	}
+	return link.Status
+}
+
+// noticeTemplate est la page servie à la place de la redirection pour un lien désactivé,
+// bloqué ou pas encore disponible.
+var noticeTemplate = template.Must(template.New("notice").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// renderUnavailable affiche la page d'un lien qui n'est plus servi : 451 pour un lien
+// bloqué à la suite d'un signalement, 410 pour un lien désactivé.
+func renderUnavailable(c *gin.Context, link *models.Link) {
+	if link.Status == models.LinkStatusBlocked {
+		renderNotice(c, http.StatusUnavailableForLegalReasons, "Lien bloqué",
+			"Ce lien court a été bloqué à la suite d'un signalement d'abus.")
+		return
+	}
+	renderNotice(c, http.StatusGone, "Lien désactivé",
+		"Ce lien court a été désactivé et ne redirige plus vers sa destination.")
+}
+
+// renderNotYetActive affiche la page d'un lien dont la date de mise en ligne n'est pas atteinte.
+func renderNotYetActive(c *gin.Context, link *models.Link) {
+	renderNotice(c, http.StatusNotFound, "Lien pas encore disponible",
+		"Ce lien court sera disponible à partir du "+link.ActiveFrom.UTC().Format("02/01/2006 à 15:04")+" (UTC).")
+}
+
+// renderNotice affiche une page d'information à la place de la redirection.
+// La réponse ne doit pas être mise en cache, l'état du lien pouvant changer.
+func renderNotice(c *gin.Context, status int, title, message string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := noticeTemplate.Execute(c.Writer, gin.H{"Title": title, "Message": message}); err != nil {
+		log.Printf("Error rendering notice page: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passwordCookiePrefix préfixe le nom du cookie d'accès d'un lien protégé.
+const passwordCookiePrefix = "lk_"
+
+// passwordGate regroupe ce qui est nécessaire pour protéger les liens par mot de passe :
+// le secret de signature des cookies d'accès, leur durée de vie et le limiteur d'essais.
+type passwordGate struct {
+	secret  []byte
+	ttl     time.Duration
+	cfg     *config.Config // Détermine si les cookies d'un domaine exigent HTTPS
+	limiter *attemptLimiter
+}
+
+// newPasswordGate crée le passwordGate à partir de la configuration. Sans secret configuré,
+// un secret aléatoire est généré : les cookies émis ne survivent alors pas à un redémarrage.
+func newPasswordGate(cfg *config.Config) *passwordGate {
+	secret := []byte(cfg.Security.CookieSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("FATAL: impossible de générer le secret des cookies: %v", err)
+		}
+		log.Println("Attention: security.cookie_secret n'est pas défini, un secret aléatoire est utilisé.")
+	}
+
+	window := time.Duration(cfg.Security.PasswordAttemptWindowMinutes) * time.Minute
+	return &passwordGate{
+		secret:  secret,
+		ttl:     time.Duration(cfg.Security.PasswordCookieTTLMinutes) * time.Minute,
+		cfg:     cfg,
+		limiter: newAttemptLimiter(cfg.Security.PasswordMaxAttempts, window),
+	}
+}
+
+// sign calcule la signature du cookie d'accès d'un lien pour une date d'expiration donnée.
+// Le hash du mot de passe entre dans la signature : changer le mot de passe invalide les cookies émis.
+func (g *passwordGate) sign(link *models.Link, expires int64) string {
+	mac := hmac.New(sha256.New, g.secret)
+	fmt.Fprintf(mac, "%s|%d|%s", services.SigningSubject(link), expires, link.PasswordHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hasAccess indique si la requête porte un cookie d'accès valide et non expiré pour le lien.
+func (g *passwordGate) hasAccess(c *gin.Context, link *models.Link) bool {
+	value, err := c.Cookie(passwordCookiePrefix + link.Shortcode)
+	if err != nil {
+		return false
+	}
+	expiresPart, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiresPart, 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(g.sign(link, expires)))
+}
+
+// grantAccess dépose le cookie d'accès signé, limité au chemin du lien.
+func (g *passwordGate) grantAccess(c *gin.Context, link *models.Link) {
+	expires := time.Now().Add(g.ttl).Unix()
+	value := strconv.FormatInt(expires, 10) + "." + g.sign(link, expires)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(passwordCookiePrefix+link.Shortcode, value, int(g.ttl.Seconds()), "/"+link.Shortcode, "", secureCookies(g.cfg, link), true)
+}
+
+// passwordFormTemplate est la page servie à la place de la redirection pour un lien protégé.
+var passwordFormTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Lien protégé</title>
+</head>
+<body>
+<h1>Ce lien est protégé par un mot de passe</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="{{.Action}}">
+<label for="password">Mot de passe</label>
+<input type="password" id="password" name="password" autocomplete="current-password" required autofocus>
+<button type="submit">Accéder au lien</button>
+</form>
+</body>
+</html>
+`))
+
+// renderPasswordForm affiche le formulaire de mot de passe avec le statut HTTP donné.
+func renderPasswordForm(c *gin.Context, status int, errorMessage string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	err := passwordFormTemplate.Execute(c.Writer, gin.H{
+		"Action": c.Request.URL.RequestURI(),
+		"Error":  errorMessage,
+	})
+	if err != nil {
+		log.Printf("Error rendering password form: %v", err)
+	}
+}
+
+// PasswordSubmitHandler vérifie le mot de passe saisi pour un lien protégé.
+// Les essais sont limités par IP et par lien ; en cas de succès, un cookie d'accès signé
+// est déposé et le visiteur est renvoyé vers l'URL courte, qui effectue alors la redirection.
+func PasswordSubmitHandler(linkService *services.LinkService, gate *passwordGate) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.GetLinkByShortCode(linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		// Un lien public, désactivé ou bloqué est traité par la route GET.
+		if !link.IsProtected() || !link.IsActive() {
+			c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+			return
+		}
+
+		attemptKey := c.ClientIP() + "|" + link.Shortcode
+		if retryAfter, ok := gate.limiter.allow(attemptKey); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			renderPasswordForm(c, http.StatusTooManyRequests, "Trop de tentatives. Réessayez plus tard.")
+			return
+		}
+
+		if !linkService.CheckPassword(link, c.PostForm("password")) {
+			renderPasswordForm(c, http.StatusUnauthorized, "Mot de passe incorrect.")
+			return
+		}
+
+		gate.limiter.reset(attemptKey)
+		gate.grantAccess(c, link)
+		c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+	}
+}
+
+// attemptLimiter compte les essais par clé sur une fenêtre fixe.
+type attemptLimiter struct {
+	max    int
+	window time.Duration
+
+	mu       sync.Mutex
+	attempts map[string]*attemptWindow
+}
+
+type attemptWindow struct {
+	count int
+	start time.Time
+}
+
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		max:      max,
+		window:   window,
+		attempts: make(map[string]*attemptWindow),
+	}
+}
+
+// allow enregistre un essai pour key. Si le nombre maximal d'essais est atteint sur la fenêtre
+// en cours, l'essai est refusé et la durée restante avant la prochaine fenêtre est retournée.
+func (l *attemptLimiter) allow(key string) (time.Duration, bool) {
+	if l.max <= 0 {
+		return 0, true
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Supprime les fenêtres expirées pour que la map ne grossisse pas indéfiniment.
+	for k, w := range l.attempts {
+		if now.Sub(w.start) >= l.window {
+			delete(l.attempts, k)
+		}
+	}
+
+	w, ok := l.attempts[key]
+	if !ok {
+		w = &attemptWindow{start: now}
+		l.attempts[key] = w
+	}
+	if w.count >= l.max {
+		return l.window - now.Sub(w.start), false
+	}
+	w.count++
+	return 0, true
+}
+
+// reset oublie les essais enregistrés pour key, après un mot de passe correct.
+func (l *attemptLimiter) reset(key string) {
+	l.mu.Lock()
+	delete(l.attempts, key)
+	l.mu.Unlock()
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EraseVisitorRequest représente le corps de la requête d'effacement des clics d'un visiteur.
+type EraseVisitorRequest struct {
+	IP        string `json:"ip"`
+	VisitorID string `json:"visitor_id"` // Empreinte enregistrée en mode analytics.ip_mode=hashed
+	DryRun    bool   `json:"dry_run"`
+}
+
+// AnonymizeRequest représente le corps de la requête d'anonymisation des anciens clics.
+type AnonymizeRequest struct {
+	OlderThanDays int  `json:"older_than_days"` // 0 pour la valeur de privacy.anonymize_after_days
+	DryRun        bool `json:"dry_run"`
+}
+
+// PurgeLinkRequest représente le corps, optionnel, de la requête de purge d'un lien.
+type PurgeLinkRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// EraseVisitorHandler supprime les clics d'un visiteur identifié par son IP ou son empreinte.
+func EraseVisitorHandler(privacy *services.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EraseVisitorRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		result, err := privacy.EraseVisitor(requestActor(c), req.IP, req.VisitorID, req.DryRun)
+		if err != nil {
+			if errors.Is(err, services.ErrNoVisitorCriteria) || errors.Is(err, services.ErrInvalidIP) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
+			log.Printf("Error erasing visitor data: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// PurgeLinkHandler supprime définitivement un lien, même déjà supprimé, et toutes ses données.
+func PurgeLinkHandler(privacy *services.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req PurgeLinkRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
+		}
+
+		result, err := privacy.PurgeLink(requestActor(c), linkDomain(c), shortCode, req.DryRun)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			log.Printf("Error purging link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// AnonymizeClicksHandler efface l'adresse IP des clics et signalements plus anciens que l'âge demandé.
+func AnonymizeClicksHandler(privacy *services.PrivacyService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AnonymizeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if req.OlderThanDays == 0 {
+			req.OlderThanDays = cfg.Privacy.AnonymizeAfterDays
+		}
+
+		result, err := privacy.AnonymizeOlderThan(requestActor(c), req.OlderThanDays, req.DryRun)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAge) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
+			log.Printf("Error anonymizing clicks: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/qrcode"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetLinkQRCodeHandler génère le QR code de l'URL courte complète d'un lien.
+// Paramètres : format (png ou svg), size (en pixels) et ecc (L, M, Q ou H).
+// L'URL encodée porte le marqueur ?src=qr pour que les scans soient identifiables dans les statistiques.
+func GetLinkQRCodeHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		size := services.DefaultQRSize
+		if raw := c.Query("size"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidQRSize.Error()})
+				return
+			}
+			size = parsed
+		}
+
+		level, err := qrcode.ParseLevel(c.Query("ecc"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLink(requestActor(c), linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		content := services.QRCodeURL(cfg.BaseURLFor(link.Domain), link.Shortcode)
+		image, contentType, err := services.RenderQRCode(content, c.DefaultQuery("format", "png"), size, level)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidQRFormat) || errors.Is(err, services.ErrInvalidQRSize) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error rendering QR code for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// La réponse dépend des droits du client : seul son propre cache peut la conserver.
+		c.Header("Cache-Control", "private, max-age=86400")
+		c.Data(http.StatusOK, contentType, image)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit décrit un seau à jetons : Requests requêtes par période Per, avec une
+// rafale maximale de Burst requêtes (Requests si Burst vaut 0).
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// capacity retourne la taille du seau.
+func (l RateLimit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate retourne le nombre de jetons ajoutés au seau par seconde.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimitResult est le résultat d'une tentative de consommation d'un jeton.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Jetons restants après la requête
+	RetryAfter time.Duration // Délai avant le prochain jeton si la requête est refusée
+	Reset      time.Duration // Délai avant que le seau soit de nouveau plein
+}
+
+// RateLimitStore conserve l'état des seaux à jetons. L'implémentation en mémoire convient
+// à une instance unique ; une implémentation partagée (Redis, etc.) peut la remplacer
+// lorsque plusieurs instances servent le même trafic.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// MemoryRateLimitStore est un RateLimitStore en mémoire, sûr pour un usage concurrent.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // Instant à partir duquel le seau est de nouveau plein
+}
+
+// NewMemoryRateLimitStore crée un MemoryRateLimitStore vide.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Take consomme un jeton du seau associé à key, s'il en reste.
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	capacity, rate := limit.capacity(), limit.rate()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	// Remplit le seau en fonction du temps écoulé depuis la dernière requête.
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep supprime, au plus une fois par minute, les seaux pleins qui n'apportent plus
+// d'information, pour que la mémoire ne grossisse pas avec le nombre de clients vus.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware limite le débit des requêtes d'une catégorie (scope) de routes.
+// Les clients sont identifiés par leur clé d'API lorsqu'ils en présentent une valide,
+// sinon par leur adresse IP. Les en-têtes RateLimit-* sont ajoutés à chaque réponse ;
+// une requête refusée reçoit un 429 avec un en-tête Retry-After.
+func RateLimitMiddleware(store RateLimitStore, scope string, limit RateLimit) gin.HandlerFunc {
+	policy := fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, int(limit.Per.Seconds()), int(limit.capacity()))
+
+	return func(c *gin.Context) {
+		if limit.Requests <= 0 || limit.Per <= 0 {
+			c.Next()
+			return
+		}
+
+		key := scope + "|" + clientIdentity(c)
+		result, err := store.Take(key, limit, time.Now())
+		if err != nil {
+			// Un store indisponible ne doit pas rendre le service indisponible.
+			log.Printf("Rate limit store error for %s: %v", scope, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policy)
+		c.Header("RateLimit-Limit", strconv.Itoa(int(limit.capacity())))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientIdentity retourne l'identifiant du client utilisé pour la limitation de débit.
+func clientIdentity(c *gin.Context) string {
+	if name, ok := c.Get(principalNameContextKey); ok {
+		return c.GetString(principalKindContextKey) + ":" + name.(string)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
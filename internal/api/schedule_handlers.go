@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ScheduleChangeRequest représente le corps de la requête JSON d'un changement de destination programmé.
+type ScheduleChangeRequest struct {
+	At      time.Time `json:"at" binding:"required"`           // Échéance du changement (RFC 3339)
+	LongURL string    `json:"long_url" binding:"required,url"` // Nouvelle destination à l'échéance
+}
+
+// ScheduleChangeHandler programme un changement de destination pour un lien.
+func ScheduleChangeHandler(scheduleService *services.ScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req ScheduleChangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		change, err := scheduleService.ScheduleChange(requestActor(c), linkDomain(c), shortCode, req.At, req.LongURL)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			if errors.Is(err, services.ErrScheduleInPast) || isValidationError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error scheduling change for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, scheduledChangeResponse(change))
+	}
+}
+
+// ListScheduledChangesHandler retourne les changements programmés d'un lien.
+func ListScheduledChangesHandler(scheduleService *services.ScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, changes, err := scheduleService.ListChanges(requestActor(c), linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error listing scheduled changes for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(changes))
+		for i := range changes {
+			items = append(items, scheduledChangeResponse(&changes[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":     link.Shortcode,
+			"active_from":    link.ActiveFrom,
+			"expires_at":     link.ExpiresAt,
+			"next_change_at": link.NextChangeAt,
+			"changes":        items,
+		})
+	}
+}
+
+// CancelScheduledChangeHandler annule un changement programmé encore en attente.
+func CancelScheduledChangeHandler(scheduleService *services.ScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": services.ErrScheduleNotFound.Error()})
+			return
+		}
+
+		if err := scheduleService.CancelChange(requestActor(c), linkDomain(c), shortCode, uint(id)); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+			case errors.Is(err, services.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			case errors.Is(err, services.ErrScheduleNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrScheduleNotPending):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Error cancelling scheduled change %d for %s: %v", id, shortCode, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// scheduledChangeResponse construit la représentation JSON d'un changement programmé.
+func scheduledChangeResponse(change *models.ScheduledChange) gin.H {
+	return gin.H{
+		"id":         change.ID,
+		"at":         change.At,
+		"long_url":   change.LongURL,
+		"status":     change.Status,
+		"error":      change.Error,
+		"created_by": change.CreatedBy,
+		"applied_at": change.AppliedAt,
+		"created_at": change.CreatedAt,
+	}
+}
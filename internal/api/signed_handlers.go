@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/signing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SignLinkRequest représente le corps de la requête JSON pour générer une URL signée.
+type SignLinkRequest struct {
+	TTLSeconds int `json:"ttl_seconds" binding:"required,min=1"` // Durée de validité de l'URL signée
+}
+
+// SignLinkHandler génère une URL signée et à durée de vie limitée pour un lien en mode signé.
+func SignLinkHandler(linkService *services.LinkService, signer *signing.Signer, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req SignLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if !signer.Enabled() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Link signing is not configured"})
+			return
+		}
+
+		// Émettre une URL d'accès revient à ouvrir le lien : le droit de modification est exigé.
+		link, err := linkService.GetEditableLink(requestActor(c), linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		ttl := time.Duration(req.TTLSeconds) * time.Second
+		maxTTL := time.Duration(cfg.Signing.MaxTTLHours) * time.Hour
+		signedURL, expires, err := services.SignedShortURL(signer, cfg.BaseURLFor(link.Domain), link, ttl, maxTTL)
+		if err != nil {
+			if errors.Is(err, services.ErrLinkNotSigned) || errors.Is(err, services.ErrInvalidTTL) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error signing link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"short_code": link.Shortcode,
+			"signed_url": signedURL,
+			"expires_at": expires,
+		})
+	}
+}
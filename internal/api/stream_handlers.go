@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LinkClickStreamHandler diffuse en Server-Sent Events les clics d'un lien, au fur et à mesure
+// de leur traitement par les workers.
+func LinkClickStreamHandler(linkService *services.LinkService, hub *workers.ClickHub, heartbeat time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+		link, err := linkService.GetLink(requestActor(c), linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error retrieving link %s for click stream: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		streamClicks(c, hub, heartbeat, func(record *workers.ClickRecord) bool {
+			return record.LinkID == link.ID
+		})
+	}
+}
+
+// WorkspaceClickStreamHandler diffuse en Server-Sent Events les clics de tous les liens de
+// l'espace de travail de la requête.
+func WorkspaceClickStreamHandler(hub *workers.ClickHub, heartbeat time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID := requestWorkspace(c).ID
+		streamClicks(c, hub, heartbeat, func(record *workers.ClickRecord) bool {
+			return record.WorkspaceID == workspaceID
+		})
+	}
+}
+
+// streamClicks abonne la requête aux clics retenus par filter et les lui envoie jusqu'à sa
+// déconnexion. Chaque clic est un événement "click" ; les clics perdus faute de place dans la
+// file de l'abonné sont signalés par un événement "dropped" portant leur nombre.
+// L'adresse IP et le user agent des visiteurs ne sont envoyés qu'aux administrateurs et aux
+// propriétaires de l'espace de travail.
+func streamClicks(c *gin.Context, hub *workers.ClickHub, heartbeat time.Duration, filter func(*workers.ClickRecord) bool) {
+	withVisitor := c.GetBool(principalAdminContextKey) ||
+		services.RoleAllows(c.GetString(workspaceRoleContextKey), services.PermissionManage)
+	sub, err := hub.Subscribe(filter)
+	if err != nil {
+		if errors.Is(err, workers.ErrTooManySubscribers) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many click streams, retry later"})
+			return
+		}
+		log.Printf("Error subscribing to click stream: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	defer hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Désactive la mise en tampon des proxys nginx
+	c.Status(http.StatusOK)
+	// Un premier commentaire envoie les en-têtes sans attendre le premier clic.
+	fmt.Fprint(c.Writer, ": stream ouvert\n\n")
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	done := c.Request.Context().Done()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-done:
+			return false
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			return true
+		case record, ok := <-sub.C:
+			if !ok {
+				// Le hub est arrêté : le serveur s'éteint.
+				return false
+			}
+			if dropped := sub.Dropped(); dropped > 0 {
+				c.SSEvent("dropped", gin.H{"count": dropped})
+			}
+			c.SSEvent("click", clickEventResponse(record, withVisitor))
+			return true
+		}
+	})
+}
+
+// clickEventResponse construit la représentation JSON d'un clic du flux en direct.
+// withVisitor ajoute l'adresse IP et le user agent du visiteur.
+func clickEventResponse(record *workers.ClickRecord, withVisitor bool) gin.H {
+	event := gin.H{
+		"link_id":    record.LinkID,
+		"domain":     record.Domain,
+		"short_code": record.ShortCode,
+		"timestamp":  record.Timestamp,
+		"referrer":   record.Referrer,
+		"source":     record.Source,
+		"country":    record.Country,
+		"region":     record.Region,
+		"version":    record.Version,
+		"variant":    record.Variant,
+	}
+	if withVisitor {
+		event["ip"] = record.IPAddress
+		event["user_agent"] = record.UserAgent
+	}
+	return event
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/geoip"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// chooseDestination détermine l'URL longue servie pour cette requête et la variante A/B
+// éventuellement tirée. Les règles de ciblage sont évaluées en premier ; sans règle
+// correspondante, la destination par défaut du lien (ou l'une de ses variantes) est servie.
+func chooseDestination(c *gin.Context, link *models.Link, locator *geoip.Locator, cfg *config.Config) (destination, variant string) {
+	if link.HasRules() {
+		visitor := services.NewVisitor(c.Request.UserAgent(), c.GetHeader("Accept-Language"))
+		visitor.Country = locator.Lookup(c.ClientIP()).Country
+		if rule := services.MatchRule(link, visitor); rule != nil {
+			return rule.LongURL, ""
+		}
+	}
+	if v := pickVariant(c, link, cfg); v != nil {
+		return v.LongURL, v.Name
+	}
+	return link.LongURL, ""
+}
+
+// ruleResponse construit la représentation JSON des règles de ciblage d'un lien,
+// dans leur ordre d'évaluation.
+func ruleResponse(link *models.Link) []gin.H {
+	rules := make([]gin.H, 0, len(link.Rules))
+	for _, r := range link.Rules {
+		rules = append(rules, gin.H{
+			"priority": r.Priority,
+			"os":       r.OS,
+			"device":   r.Device,
+			"language": r.Language,
+			"country":  r.Country,
+			"long_url": r.LongURL,
+		})
+	}
+	return rules
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// variantCookiePrefix préfixe le nom du cookie mémorisant la variante A/B servie à un visiteur.
+const variantCookiePrefix = "ab_"
+
+// variantCookieMaxAge est la durée de vie du cookie de variante : 30 jours.
+const variantCookieMaxAge = 30 * 24 * 60 * 60
+
+// pickVariant choisit la variante A/B servie pour cette requête, ou nil si le lien n'a pas
+// de variantes. Pour un lien "collant", la variante mémorisée dans le cookie du visiteur est
+// réutilisée tant qu'elle existe, sinon une nouvelle variante est tirée et mémorisée.
+func pickVariant(c *gin.Context, link *models.Link, cfg *config.Config) *models.LinkVariant {
+	if !link.HasVariants() {
+		return nil
+	}
+	if !link.StickyVariants {
+		return services.ChooseVariant(link.Variants)
+	}
+
+	cookieName := variantCookiePrefix + link.Shortcode
+	if name, err := c.Cookie(cookieName); err == nil {
+		if variant := services.FindVariant(link, name); variant != nil {
+			return variant
+		}
+	}
+	variant := services.ChooseVariant(link.Variants)
+	if variant != nil {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(cookieName, variant.Name, variantCookieMaxAge, "/"+link.Shortcode, "", secureCookies(cfg, link), true)
+	}
+	return variant
+}
+
+// variantResponse construit la représentation JSON des variantes A/B d'un lien.
+func variantResponse(link *models.Link) []gin.H {
+	variants := make([]gin.H, 0, len(link.Variants))
+	for _, v := range link.Variants {
+		variants = append(variants, gin.H{
+			"name":     v.Name,
+			"long_url": v.LongURL,
+			"weight":   v.Weight,
+		})
+	}
+	return variants
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RollbackLinkRequest représente le corps de la requête JSON d'un retour en arrière.
+type RollbackLinkRequest struct {
+	Version int `json:"version" binding:"required,min=1"` // Version dont la destination est rétablie
+}
+
+// GetLinkVersionsHandler retourne l'historique des destinations d'un lien, avec le nombre
+// de clics reçus pendant que chaque version était active.
+func GetLinkVersionsHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, versions, err := linkService.GetLinkVersions(requestActor(c), linkDomain(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+				return
+			}
+			log.Printf("Error retrieving versions for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		clicksByVersion, err := linkService.GetClicksByVersion(link)
+		if err != nil {
+			log.Printf("Error retrieving clicks by version for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		clicks := make(map[int]int, len(clicksByVersion))
+		for _, row := range clicksByVersion {
+			clicks[row.Version] = row.Count
+		}
+
+		items := make([]gin.H, 0, len(versions))
+		for _, v := range versions {
+			items = append(items, gin.H{
+				"version":       v.Version,
+				"long_url":      v.LongURL,
+				"restored_from": v.RestoredFrom,
+				"created_by":    v.CreatedBy,
+				"created_at":    v.CreatedAt,
+				"active":        v.Version == link.Version,
+				"clicks":        clicks[v.Version],
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":      link.Shortcode,
+			"current_version": link.Version,
+			"versions":        items,
+		})
+	}
+}
+
+// RollbackLinkHandler rétablit la destination d'une version précédente d'un lien.
+func RollbackLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req RollbackLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		link, err := linkService.RollbackLink(requestActor(c), linkDomain(c), shortCode, req.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+			case errors.Is(err, services.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			case errors.Is(err, services.ErrVersionNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrVersionAlreadyActive):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case isValidationError(err):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Error rolling back link %s: %v", shortCode, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link, cfg))
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Nombre de lettres mortes retournées par défaut et au maximum.
+const (
+	defaultDeadLettersLimit = 50
+	maxDeadLettersLimit     = 500
+)
+
+// CreateWebhookRequest représente un abonnement à des événements.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"` // Généré s'il est absent
+	Events []string `json:"events" binding:"required"`
+}
+
+// CreateWebhookHandler abonne une URL à des événements. Le secret de signature n'est
+// retourné qu'à cette occasion.
+func CreateWebhookHandler(webhooks *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateWebhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		webhook, err := webhooks.CreateWebhook(req.URL, req.Secret, req.Events)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidWebhookURL) || errors.Is(err, services.ErrInvalidWebhookEvents) ||
+				errors.Is(err, services.ErrInvalidWebhookSecret) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
+			log.Printf("Error creating webhook: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		response := webhookResponse(webhook)
+		response["secret"] = webhook.Secret
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// ListWebhooksHandler retourne les abonnements, sans leur secret.
+func ListWebhooksHandler(webhooks *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := webhooks.ListWebhooks()
+		if err != nil {
+			log.Printf("Error listing webhooks: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(list))
+		for i := range list {
+			items = append(items, webhookResponse(&list[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": items})
+	}
+}
+
+// DeleteWebhookHandler supprime un abonnement avec ses envois en attente et ses lettres mortes.
+func DeleteWebhookHandler(webhooks *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook non trouvé"})
+			return
+		}
+
+		if err := webhooks.DeleteWebhook(uint(id)); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Webhook non trouvé"})
+				return
+			}
+			log.Printf("Error deleting webhook %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListDeadLettersHandler retourne les envois abandonnés (?webhook_id=&limit=50).
+func ListDeadLettersHandler(webhooks *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webhookID uint64
+		if value := c.Query("webhook_id"); value != "" {
+			var err error
+			if webhookID, err = strconv.ParseUint(value, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: invalid webhook_id"})
+				return
+			}
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeadLettersLimit)))
+		if err != nil || limit < 1 || limit > maxDeadLettersLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: limit must be between 1 and 500"})
+			return
+		}
+
+		dead, err := webhooks.ListDeadLetters(uint(webhookID), limit)
+		if err != nil {
+			log.Printf("Error listing webhook dead letters: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(dead))
+		for _, d := range dead {
+			items = append(items, gin.H{
+				"id":         d.ID,
+				"webhook_id": d.WebhookID,
+				"event_id":   d.EventID,
+				"event":      d.Event,
+				"attempts":   d.Attempts,
+				"last_error": d.LastError,
+				"failed_at":  d.FailedAt,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"dead_letters": items})
+	}
+}
+
+// RedeliverHandler remet un envoi abandonné dans la file des envois.
+func RedeliverHandler(webhooks *services.WebhookService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Envoi non trouvé"})
+			return
+		}
+
+		delivery, err := webhooks.Redeliver(uint(id))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Envoi non trouvé"})
+				return
+			}
+			log.Printf("Error redelivering webhook dead letter %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"delivery_id": delivery.ID,
+			"webhook_id":  delivery.WebhookID,
+			"event_id":    delivery.EventID,
+			"event":       delivery.Event,
+		})
+	}
+}
+
+// webhookResponse construit la représentation JSON d'un abonnement, sans son secret.
+func webhookResponse(webhook *models.Webhook) gin.H {
+	return gin.H{
+		"id":         webhook.ID,
+		"url":        webhook.URL,
+		"events":     strings.Split(webhook.Events, ","),
+		"created_at": webhook.CreatedAt,
+	}
+}
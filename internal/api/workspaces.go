@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// workspaceHeader est l'en-tête dans lequel les clients désignent l'espace de travail au nom
+// duquel ils agissent.
+const workspaceHeader = "X-Workspace"
+
+// Clés du contexte Gin sous lesquelles sont stockés l'espace de travail de la requête
+// et le rôle qu'y tient le client.
+const (
+	workspaceContextKey     = "workspace"
+	workspaceRoleContextKey = "workspace_role"
+)
+
+// sharedViewContextKey indique si le client peut consulter les liens hors de tout espace.
+const sharedViewContextKey = "shared_view"
+
+// SetMemberRequest représente le rôle donné à un membre d'un espace de travail.
+type SetMemberRequest struct {
+	Role string `json:"role" binding:"required"` // owner, editor ou viewer
+}
+
+// WorkspaceMiddleware détermine l'espace de travail au nom duquel agit le client, à partir de
+// l'en-tête X-Workspace, et vérifie son rôle : viewer suffit pour les lectures (GET), editor est
+// exigé pour les autres méthodes. Les clés d'administration ont tous les droits sur tous les
+// espaces. Sans en-tête, la requête ne porte que sur les liens hors de tout espace, que seuls
+// les administrateurs gèrent et que les autres clients ne consultent que si
+// workspaces.shared_link_access vaut "view".
+// Il doit être placé après APIKeyMiddleware et BearerTokenMiddleware.
+func WorkspaceMiddleware(workspaces *services.WorkspaceService, cfg *config.Config) gin.HandlerFunc {
+	sharedView := cfg.Workspaces.SharedLinkAccess == "view"
+	return func(c *gin.Context) {
+		c.Set(sharedViewContextKey, sharedView)
+		slug := c.GetHeader(workspaceHeader)
+		if slug == "" {
+			c.Next()
+			return
+		}
+		if _, ok := c.Get(principalNameContextKey); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+
+		var workspace *models.Workspace
+		var role string
+		var err error
+		if c.GetBool(principalAdminContextKey) {
+			role = models.WorkspaceRoleOwner
+			if workspace, err = workspaces.GetWorkspace(slug); errors.Is(err, gorm.ErrRecordNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+				return
+			}
+		} else {
+			workspace, role, err = workspaces.Membership(slug, clientIdentity(c))
+			if errors.Is(err, services.ErrNotMember) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not a member of this workspace"})
+				return
+			}
+		}
+		if err != nil {
+			log.Printf("Error resolving workspace %s: %v", slug, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		perm := services.PermissionEdit
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			perm = services.PermissionView
+		}
+		if !services.RoleAllows(role, perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient workspace role"})
+			return
+		}
+
+		c.Set(workspaceContextKey, workspace)
+		c.Set(workspaceRoleContextKey, role)
+		c.Next()
+	}
+}
+
+// requireWorkspace réserve une route aux clients agissant au nom d'un espace de travail
+// avec un rôle accordant perm. Il doit être placé après WorkspaceMiddleware.
+func requireWorkspace(perm services.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(workspaceContextKey); !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + workspaceHeader + " header required"})
+			return
+		}
+		if !services.RoleAllows(c.GetString(workspaceRoleContextKey), perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient workspace role"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestWorkspace retourne l'espace de travail de la requête, nil sans en-tête X-Workspace.
+func requestWorkspace(c *gin.Context) *models.Workspace {
+	if workspace, ok := c.Get(workspaceContextKey); ok {
+		return workspace.(*models.Workspace)
+	}
+	return nil
+}
+
+// ListMembersHandler retourne les membres de l'espace de travail de la requête.
+func ListMembersHandler(workspaces *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspace := requestWorkspace(c)
+		_, members, err := workspaces.Members(workspace.Slug)
+		if err != nil {
+			log.Printf("Error listing members of workspace %s: %v", workspace.Slug, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(members))
+		for i := range members {
+			items = append(items, memberResponse(&members[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"workspace": workspace.Slug, "name": workspace.Name, "members": items})
+	}
+}
+
+// SetMemberHandler ajoute un membre à l'espace de travail de la requête ou change son rôle.
+func SetMemberHandler(workspaces *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspace := requestWorkspace(c)
+
+		var req SetMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		member, err := workspaces.SetMember(workspace.Slug, c.Param("member"), req.Role)
+		if err != nil {
+			if isMembershipError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error setting member of workspace %s: %v", workspace.Slug, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, memberResponse(member))
+	}
+}
+
+// RemoveMemberHandler retire un membre de l'espace de travail de la requête.
+func RemoveMemberHandler(workspaces *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspace := requestWorkspace(c)
+
+		if err := workspaces.RemoveMember(workspace.Slug, c.Param("member")); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+				return
+			}
+			if isMembershipError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error removing member of workspace %s: %v", workspace.Slug, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// isMembershipError indique si err est une erreur de validation d'un changement de membre.
+func isMembershipError(err error) bool {
+	return errors.Is(err, services.ErrInvalidMember) ||
+		errors.Is(err, services.ErrInvalidRole) ||
+		errors.Is(err, services.ErrLastOwner)
+}
+
+// memberResponse construit la représentation JSON d'un membre d'espace de travail.
+func memberResponse(member *models.WorkspaceMember) gin.H {
+	return gin.H{
+		"member":     member.Member,
+		"role":       member.Role,
+		"created_at": member.CreatedAt,
+	}
+}
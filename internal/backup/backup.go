@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Backup crée une copie cohérente de la base SQLite dans le fichier outPath.
+// Elle utilise 'VACUUM INTO', qui lit la base dans une transaction et peut donc être
+// exécutée pendant que le serveur continue d'écrire dans le fichier d'origine.
+func Backup(db *gorm.DB, outPath string) error {
+	if _, err := os.Stat(outPath); err == nil {
+		return fmt.Errorf("backup file %s already exists", outPath)
+	}
+
+	if dir := filepath.Dir(outPath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+	}
+
+	if err := db.Exec("VACUUM INTO ?", outPath).Error; err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// Restore remplace le fichier de base dbPath par la sauvegarde backupPath.
+// La sauvegarde est d'abord vérifiée (intégrité et version du schéma), puis copiée
+// à côté de la base et renommée par-dessus, pour que le remplacement soit atomique.
+// Le serveur ne doit pas être en cours d'exécution pendant la restauration.
+// Elle retourne la version du schéma de la sauvegarde restaurée.
+func Restore(dbPath, backupPath string) (int, error) {
+	version, err := Verify(backupPath)
+	if err != nil {
+		return 0, err
+	}
+
+	tmpPath := dbPath + ".restore-tmp"
+	if err := copyFile(backupPath, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to swap database file: %w", err)
+	}
+
+	// Les fichiers WAL/SHM de l'ancienne base ne correspondent plus au nouveau fichier.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("failed to remove stale %s file: %w", suffix, err)
+		}
+	}
+	return version, nil
+}
+
+// Verify ouvre une sauvegarde, contrôle son intégrité et retourne la version de son schéma.
+// Une sauvegarde sans version (base non migrée) ou produite par une version plus récente
+// de l'application est refusée.
+func Verify(backupPath string) (int, error) {
+	if _, err := os.Stat(backupPath); err != nil {
+		return 0, fmt.Errorf("backup file not found: %w", err)
+	}
+
+	db, err := gorm.Open(sqlite.Open(backupPath+"?mode=ro"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer sqlDB.Close()
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return 0, fmt.Errorf("failed to check backup integrity: %w", err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("backup is corrupted: %s", result)
+	}
+
+	version, err := database.ReadSchemaVersion(db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read backup schema version: %w", err)
+	}
+	if version == 0 {
+		return 0, errors.New("backup has no schema version, it was not created by this application")
+	}
+	if version > database.SchemaVersion {
+		return 0, fmt.Errorf("backup schema version %d is newer than supported version %d", version, database.SchemaVersion)
+	}
+	return version, nil
+}
+
+// copyFile copie src vers dst et force l'écriture sur disque avant de rendre la main.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// filePrefix préfixe les sauvegardes planifiées, pour que la rotation ne touche
+// jamais à d'autres fichiers présents dans le dossier.
+const filePrefix = "url_shortener-"
+
+// Scheduler crée périodiquement une sauvegarde de la base et ne conserve
+// que les 'retention' sauvegardes les plus récentes.
+type Scheduler struct {
+	db        *gorm.DB
+	dir       string
+	interval  time.Duration
+	retention int
+}
+
+// NewScheduler crée un Scheduler qui écrit ses sauvegardes dans dir.
+// L'intervalle doit être strictement positif.
+func NewScheduler(db *gorm.DB, dir string, interval time.Duration, retention int) (*Scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid backup interval %v", interval)
+	}
+	return &Scheduler{
+		db:        db,
+		dir:       dir,
+		interval:  interval,
+		retention: retention,
+	}, nil
+}
+
+// Start lance la boucle de sauvegarde. Elle est bloquante et doit être appelée dans une goroutine.
+func (s *Scheduler) Start() {
+	log.Printf("[BACKUP] Sauvegardes planifiées toutes les %v dans '%s' (conservation: %d).", s.interval, s.dir, s.retention)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.run()
+	}
+}
+
+// run crée une nouvelle sauvegarde horodatée puis supprime les plus anciennes.
+func (s *Scheduler) run() {
+	name := filePrefix + time.Now().UTC().Format("20060102T150405Z") + ".db"
+	path := filepath.Join(s.dir, name)
+
+	if err := Backup(s.db, path); err != nil {
+		log.Printf("[BACKUP] ERREUR lors de la sauvegarde : %v", err)
+		return
+	}
+	log.Printf("[BACKUP] Sauvegarde créée : %s", path)
+
+	if err := s.prune(); err != nil {
+		log.Printf("[BACKUP] ERREUR lors de la rotation des sauvegardes : %v", err)
+	}
+}
+
+// prune supprime les sauvegardes planifiées au-delà du nombre à conserver.
+// Les noms de fichiers étant horodatés, l'ordre alphabétique est aussi l'ordre chronologique.
+func (s *Scheduler) prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), filePrefix) && strings.HasSuffix(entry.Name(), ".db") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > s.retention {
+		path := filepath.Join(s.dir, backups[0])
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		log.Printf("[BACKUP] Ancienne sauvegarde supprimée : %s", path)
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -2,6 +2,11 @@ package config
 
 import (
 	"log" // Pour logger les informations ou erreurs de chargement de config
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/signing"
 
 	"github.com/spf13/viper" // La bibliothèque pour la gestion de configuration
 )
@@ -10,22 +15,181 @@ import (
 
 type Config struct {
 	Server struct {
-		Port    int    `mapstructure:"port"`
-		BaseURL string `mapstructure:"base_url"`
+		Port                int    `mapstructure:"port"`
+		BaseURL             string `mapstructure:"base_url"`
+		DefaultRedirectType int    `mapstructure:"default_redirect_type"`
+		RedirectCacheMaxAge int    `mapstructure:"redirect_cache_max_age"`
+		// Proxys (IP ou CIDR) dont les en-têtes de transfert sont crus pour déterminer l'IP du client
+		TrustedProxies   []string `mapstructure:"trusted_proxies"`
+		ForwardedHeaders []string `mapstructure:"forwarded_headers"`
 	} `mapstructure:"server"`
 
+	// Domaines courts supplémentaires. Le domaine de server.base_url reste le domaine principal.
+	Domains []DomainConfig `mapstructure:"domains"`
+
 	Database struct {
 		Name string `mapstructure:"name"`
 	} `mapstructure:"database"`
 
 	Analytics struct {
-		BufferSize  int `mapstructure:"buffer_size"`
-		WorkerCount int `mapstructure:"worker_count"`
+		BufferSize   int    `mapstructure:"buffer_size"`
+		WorkerCount  int    `mapstructure:"worker_count"`
+		IPMode       string `mapstructure:"ip_mode"`        // full, truncated, hashed ou none
+		IPHashSecret string `mapstructure:"ip_hash_secret"` // Clé HMAC du mode hashed
 	} `mapstructure:"analytics"`
 
+	// Destinations des clics traités par les workers
+	ClickSinks struct {
+		Database struct {
+			Enabled bool `mapstructure:"enabled"`
+		} `mapstructure:"database"`
+		File struct {
+			Enabled    bool   `mapstructure:"enabled"`
+			Path       string `mapstructure:"path"`
+			MaxSizeMB  int    `mapstructure:"max_size_mb"` // Taille déclenchant la rotation du fichier
+			MaxBackups int    `mapstructure:"max_backups"` // Nombre d'archives conservées
+		} `mapstructure:"file"`
+		Stdout struct {
+			Enabled bool `mapstructure:"enabled"`
+		} `mapstructure:"stdout"`
+		HTTP struct {
+			Enabled              bool              `mapstructure:"enabled"`
+			URL                  string            `mapstructure:"url"`
+			Headers              map[string]string `mapstructure:"headers"`
+			BatchSize            int               `mapstructure:"batch_size"`
+			FlushIntervalSeconds int               `mapstructure:"flush_interval_seconds"`
+			TimeoutSeconds       int               `mapstructure:"timeout_seconds"`
+			MaxAttempts          int               `mapstructure:"max_attempts"`
+			MaxBuffer            int               `mapstructure:"max_buffer"`
+		} `mapstructure:"http"`
+	} `mapstructure:"click_sinks"`
+
+	// Flux de clics en direct (Server-Sent Events)
+	ClickStream struct {
+		BufferSize       int `mapstructure:"buffer_size"`       // Clics en attente par abonné, au-delà ils sont perdus pour lui
+		MaxSubscribers   int `mapstructure:"max_subscribers"`   // Nombre maximal de flux ouverts simultanément
+		HeartbeatSeconds int `mapstructure:"heartbeat_seconds"` // Intervalle des commentaires gardant la connexion ouverte
+	} `mapstructure:"click_stream"`
+
 	Monitor struct {
-		IntervalMinutes int `mapstructure:"interval_minutes"`
+		IntervalMinutes  int    `mapstructure:"interval_minutes"`
+		TimeoutSeconds   int    `mapstructure:"timeout_seconds"`
+		MaxRedirects     int    `mapstructure:"max_redirects"`
+		MaxResponseBytes int64  `mapstructure:"max_response_bytes"`
+		UserAgent        string `mapstructure:"user_agent"`
 	} `mapstructure:"monitor"`
+
+	Scheduler struct {
+		IntervalSeconds int `mapstructure:"interval_seconds"`
+		BatchSize       int `mapstructure:"batch_size"`
+	} `mapstructure:"scheduler"`
+
+	Workspaces struct {
+		// Accès des clients non administrateurs aux liens qui n'appartiennent à aucun espace de
+		// travail : "none" (réservés aux administrateurs) ou "view" (consultation seule).
+		SharedLinkAccess string `mapstructure:"shared_link_access"`
+	} `mapstructure:"workspaces"`
+
+	Webhooks struct {
+		IntervalSeconds       int  `mapstructure:"interval_seconds"`
+		BatchSize             int  `mapstructure:"batch_size"`
+		Workers               int  `mapstructure:"workers"`
+		MaxAttempts           int  `mapstructure:"max_attempts"`
+		BackoffBaseSeconds    int  `mapstructure:"backoff_base_seconds"`
+		BackoffMaxSeconds     int  `mapstructure:"backoff_max_seconds"`
+		TimeoutSeconds        int  `mapstructure:"timeout_seconds"`
+		AllowPrivateAddresses bool `mapstructure:"allow_private_addresses"` // Autorise les webhooks du réseau interne
+	} `mapstructure:"webhooks"`
+
+	Auth struct {
+		APIKeys []struct {
+			Name  string `mapstructure:"name"`
+			Key   string `mapstructure:"key"`
+			Admin bool   `mapstructure:"admin"` // Donne accès aux routes de modération /api/v1/admin
+		} `mapstructure:"api_keys"`
+		JWT struct {
+			JWKSURL        string   `mapstructure:"jwks_url"`  // Jeu de clés publiques du fournisseur d'identité
+			JWKSFile       string   `mapstructure:"jwks_file"` // Jeu de clés local, utilisé si jwks_url est vide
+			Issuer         string   `mapstructure:"issuer"`
+			Audience       string   `mapstructure:"audience"`
+			UserClaim      string   `mapstructure:"user_claim"`   // Claim donnant le nom de l'utilisateur
+			AdminClaim     string   `mapstructure:"admin_claim"`  // Claim donnant les droits d'administration
+			AdminValues    []string `mapstructure:"admin_values"` // Valeurs de admin_claim accordant ces droits
+			RefreshMinutes int      `mapstructure:"refresh_minutes"`
+			LeewaySeconds  int      `mapstructure:"leeway_seconds"`
+		} `mapstructure:"jwt"`
+	} `mapstructure:"auth"`
+
+	RateLimit struct {
+		Enabled  bool            `mapstructure:"enabled"`
+		Create   RateLimitConfig `mapstructure:"create"`
+		Redirect RateLimitConfig `mapstructure:"redirect"`
+		Stats    RateLimitConfig `mapstructure:"stats"`
+	} `mapstructure:"rate_limit"`
+
+	URLPolicy struct {
+		AllowedSchemes         []string `mapstructure:"allowed_schemes"`
+		BlockPrivateAddresses  bool     `mapstructure:"block_private_addresses"`
+		ResolveHostnames       bool     `mapstructure:"resolve_hostnames"`
+		AllowDomains           []string `mapstructure:"allow_domains"`
+		DenyDomains            []string `mapstructure:"deny_domains"`
+		BlocklistFile          string   `mapstructure:"blocklist_file"`
+		BlocklistReloadSeconds int      `mapstructure:"blocklist_reload_seconds"`
+	} `mapstructure:"url_policy"`
+
+	Privacy struct {
+		AnonymizeAfterDays int `mapstructure:"anonymize_after_days"` // Âge par défaut des clics à anonymiser
+	} `mapstructure:"privacy"`
+
+	GeoIP struct {
+		DatabaseFile  string `mapstructure:"database_file"`  // Base .mmdb au format MaxMind, vide pour désactiver
+		ReloadSeconds int    `mapstructure:"reload_seconds"` // Intervalle de vérification des mises à jour de la base
+	} `mapstructure:"geoip"`
+
+	Security struct {
+		CookieSecret                 string `mapstructure:"cookie_secret"`
+		PasswordCookieTTLMinutes     int    `mapstructure:"password_cookie_ttl_minutes"`
+		PasswordMaxAttempts          int    `mapstructure:"password_max_attempts"`
+		PasswordAttemptWindowMinutes int    `mapstructure:"password_attempt_window_minutes"`
+	} `mapstructure:"security"`
+
+	Signing struct {
+		ActiveKeyID string `mapstructure:"active_key_id"`
+		MaxTTLHours int    `mapstructure:"max_ttl_hours"`
+		Keys        []struct {
+			ID     string `mapstructure:"id"`
+			Secret string `mapstructure:"secret"`
+		} `mapstructure:"keys"`
+	} `mapstructure:"signing"`
+
+	Retention struct {
+		Enabled         bool `mapstructure:"enabled"`
+		RawDays         int  `mapstructure:"raw_days"`
+		IntervalMinutes int  `mapstructure:"interval_minutes"`
+		BatchSize       int  `mapstructure:"batch_size"`
+	} `mapstructure:"retention"`
+
+	Backup struct {
+		Enabled         bool   `mapstructure:"enabled"`
+		Dir             string `mapstructure:"dir"`
+		IntervalMinutes int    `mapstructure:"interval_minutes"`
+		Retention       int    `mapstructure:"retention"`
+	} `mapstructure:"backup"`
+}
+
+// DomainConfig décrit un domaine court supplémentaire : les liens qui lui sont rattachés
+// forment un espace de codes courts distinct de celui du domaine principal.
+type DomainConfig struct {
+	Host    string `mapstructure:"host"`     // Nom d'hôte reçu dans l'en-tête Host, ex: "go.example.com"
+	BaseURL string `mapstructure:"base_url"` // URL de base des URLs courtes du domaine, "https://<host>" par défaut
+}
+
+// RateLimitConfig décrit la limite de débit d'une catégorie de routes :
+// 'requests' requêtes toutes les 'per_seconds' secondes, avec une rafale maximale de 'burst'.
+type RateLimitConfig struct {
+	Requests   int `mapstructure:"requests"`
+	PerSeconds int `mapstructure:"per_seconds"`
+	Burst      int `mapstructure:"burst"`
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -40,10 +204,92 @@ func LoadConfig() (*Config, error) {
 	// Valeurs par défaut si le fichier de config est absent ou incomplet
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.default_redirect_type", 302)
+	viper.SetDefault("server.redirect_cache_max_age", 86400)
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.forwarded_headers", []string{"X-Forwarded-For", "X-Real-IP"})
+	viper.SetDefault("domains", []DomainConfig{})
 	viper.SetDefault("database.name", "urlshortener.db")
 	viper.SetDefault("analytics.buffer_size", 100)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.ip_mode", "full")
+	viper.SetDefault("analytics.ip_hash_secret", "")
+	viper.SetDefault("click_sinks.database.enabled", true)
+	viper.SetDefault("click_sinks.file.enabled", false)
+	viper.SetDefault("click_sinks.file.path", "clicks/clicks.ndjson")
+	viper.SetDefault("click_sinks.file.max_size_mb", 100)
+	viper.SetDefault("click_sinks.file.max_backups", 10)
+	viper.SetDefault("click_sinks.stdout.enabled", false)
+	viper.SetDefault("click_sinks.http.enabled", false)
+	viper.SetDefault("click_sinks.http.url", "")
+	viper.SetDefault("click_sinks.http.headers", map[string]string{})
+	viper.SetDefault("click_sinks.http.batch_size", 100)
+	viper.SetDefault("click_sinks.http.flush_interval_seconds", 5)
+	viper.SetDefault("click_sinks.http.timeout_seconds", 10)
+	viper.SetDefault("click_sinks.http.max_attempts", 3)
+	viper.SetDefault("click_sinks.http.max_buffer", 10000)
+	viper.SetDefault("click_stream.buffer_size", 64)
+	viper.SetDefault("click_stream.max_subscribers", 100)
+	viper.SetDefault("click_stream.heartbeat_seconds", 15)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("scheduler.interval_seconds", 30)
+	viper.SetDefault("scheduler.batch_size", 100)
+	viper.SetDefault("workspaces.shared_link_access", "none")
+	viper.SetDefault("webhooks.interval_seconds", 5)
+	viper.SetDefault("webhooks.batch_size", 100)
+	viper.SetDefault("webhooks.workers", 4)
+	viper.SetDefault("webhooks.max_attempts", 8)
+	viper.SetDefault("webhooks.backoff_base_seconds", 30)
+	viper.SetDefault("webhooks.backoff_max_seconds", 3600)
+	viper.SetDefault("webhooks.timeout_seconds", 10)
+	viper.SetDefault("webhooks.allow_private_addresses", false)
+	viper.SetDefault("monitor.timeout_seconds", 5)
+	viper.SetDefault("monitor.max_redirects", 5)
+	viper.SetDefault("monitor.max_response_bytes", 1<<20)
+	viper.SetDefault("monitor.user_agent", "urlshortener-monitor/1.0")
+	viper.SetDefault("auth.jwt.jwks_url", "")
+	viper.SetDefault("auth.jwt.jwks_file", "")
+	viper.SetDefault("auth.jwt.issuer", "")
+	viper.SetDefault("auth.jwt.audience", "")
+	viper.SetDefault("auth.jwt.user_claim", "sub")
+	viper.SetDefault("auth.jwt.admin_claim", "")
+	viper.SetDefault("auth.jwt.admin_values", []string{})
+	viper.SetDefault("auth.jwt.refresh_minutes", 60)
+	viper.SetDefault("auth.jwt.leeway_seconds", 60)
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.create.requests", 30)
+	viper.SetDefault("rate_limit.create.per_seconds", 60)
+	viper.SetDefault("rate_limit.create.burst", 10)
+	viper.SetDefault("rate_limit.redirect.requests", 600)
+	viper.SetDefault("rate_limit.redirect.per_seconds", 60)
+	viper.SetDefault("rate_limit.redirect.burst", 100)
+	viper.SetDefault("rate_limit.stats.requests", 120)
+	viper.SetDefault("rate_limit.stats.per_seconds", 60)
+	viper.SetDefault("rate_limit.stats.burst", 30)
+	viper.SetDefault("url_policy.allowed_schemes", []string{"http", "https"})
+	viper.SetDefault("url_policy.block_private_addresses", true)
+	viper.SetDefault("url_policy.resolve_hostnames", true)
+	viper.SetDefault("url_policy.allow_domains", []string{})
+	viper.SetDefault("url_policy.deny_domains", []string{})
+	viper.SetDefault("url_policy.blocklist_file", "")
+	viper.SetDefault("url_policy.blocklist_reload_seconds", 30)
+	viper.SetDefault("privacy.anonymize_after_days", 30)
+	viper.SetDefault("geoip.database_file", "")
+	viper.SetDefault("geoip.reload_seconds", 300)
+	viper.SetDefault("security.cookie_secret", "")
+	viper.SetDefault("security.password_cookie_ttl_minutes", 30)
+	viper.SetDefault("security.password_max_attempts", 5)
+	viper.SetDefault("security.password_attempt_window_minutes", 15)
+	viper.SetDefault("signing.active_key_id", "")
+	viper.SetDefault("signing.max_ttl_hours", 720)
+	viper.SetDefault("retention.enabled", false)
+	viper.SetDefault("retention.raw_days", 90)
+	viper.SetDefault("retention.interval_minutes", 60)
+	viper.SetDefault("retention.batch_size", 1000)
+	viper.SetDefault("backup.enabled", false)
+	viper.SetDefault("backup.dir", "backups")
+	viper.SetDefault("backup.interval_minutes", 60)
+	viper.SetDefault("backup.retention", 24)
 
 	// Lis le fichier de configuration.
 	if err := viper.ReadInConfig(); err != nil {
@@ -63,3 +309,73 @@ func LoadConfig() (*Config, error) {
 
 	return &cfg, nil // Retourne la configuration chargée
 }
+
+// SigningKeys retourne les clés de signature des liens signés configurées.
+func (c *Config) SigningKeys() []signing.Key {
+	keys := make([]signing.Key, 0, len(c.Signing.Keys))
+	for _, key := range c.Signing.Keys {
+		keys = append(keys, signing.Key{ID: key.ID, Secret: []byte(key.Secret)})
+	}
+	return keys
+}
+
+// PrimaryHost retourne le nom d'hôte du domaine principal, tiré de server.base_url.
+func (c *Config) PrimaryHost() string {
+	base, err := url.Parse(c.Server.BaseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(base.Hostname())
+}
+
+// LookupDomain associe un nom d'hôte, avec ou sans port, au domaine d'un lien.
+// Le domaine principal est représenté par la chaîne vide. ok vaut false si l'hôte
+// ne correspond à aucun domaine configuré.
+func (c *Config) LookupDomain(host string) (domain string, ok bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "" || host == c.PrimaryHost() {
+		return "", true
+	}
+	for _, d := range c.Domains {
+		if strings.ToLower(d.Host) == host {
+			return host, true
+		}
+	}
+	return "", false
+}
+
+// DomainHosts retourne les noms d'hôte de tous les domaines courts, principal compris.
+func (c *Config) DomainHosts() []string {
+	hosts := []string{}
+	if primary := c.PrimaryHost(); primary != "" {
+		hosts = append(hosts, primary)
+	}
+	for _, d := range c.Domains {
+		hosts = append(hosts, strings.ToLower(d.Host))
+	}
+	return hosts
+}
+
+// BaseURLFor retourne l'URL de base des URLs courtes d'un domaine, sans barre finale.
+func (c *Config) BaseURLFor(domain string) string {
+	if domain != "" {
+		for _, d := range c.Domains {
+			if strings.ToLower(d.Host) != domain {
+				continue
+			}
+			if d.BaseURL != "" {
+				return strings.TrimRight(d.BaseURL, "/")
+			}
+			return "https://" + domain
+		}
+	}
+	return strings.TrimRight(c.Server.BaseURL, "/")
+}
+
+// ShortURL retourne l'URL courte complète d'un code sur un domaine.
+func (c *Config) ShortURL(domain, shortCode string) string {
+	return c.BaseURLFor(domain) + "/" + shortCode
+}
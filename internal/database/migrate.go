@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// SchemaVersion est la version courante du schéma de la base de données.
+// Elle est stockée dans le PRAGMA 'user_version' de SQLite à chaque migration,
+// ce qui permet de vérifier la compatibilité d'une sauvegarde avant de la restaurer.
+// Incrémentez-la à chaque modification des modèles GORM.
+const SchemaVersion = 18
+
+// Migrate exécute les migrations automatiques de GORM pour tous les modèles de l'application,
+// puis enregistre la version du schéma dans la base.
+func Migrate(db *gorm.DB) error {
+	current, err := ReadSchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	// Les modifications qu'AutoMigrate ne sait pas faire seul sont appliquées avant lui.
+	if err := upgrade(db, current); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(
+		&models.Link{},
+		&models.Click{},
+		&models.ClickDailyRollup{},
+		&models.AbuseReport{},
+		&models.LinkStatusChange{},
+		&models.AuditEvent{},
+		&models.LinkVersion{},
+		&models.ScheduledChange{},
+		&models.LinkVariant{},
+		&models.TargetingRule{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeadLetter{},
+	); err != nil {
+		return err
+	}
+
+	// Les données que les nouvelles tables doivent reprendre sont copiées après AutoMigrate.
+	if err := backfill(db, current); err != nil {
+		return err
+	}
+
+	// Le journal d'audit est en ajout seul : toute modification ou suppression est refusée.
+	for _, stmt := range auditTriggers {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create audit trigger: %w", err)
+		}
+	}
+
+	// PRAGMA n'accepte pas de paramètres liés, la version est une constante entière.
+	if err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)).Error; err != nil {
+		return fmt.Errorf("failed to store schema version: %w", err)
+	}
+	return nil
+}
+
+// auditTriggers empêchent la modification et la suppression des événements d'audit.
+var auditTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+	BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+}
+
+// upgrade applique les étapes de migration manuelles nécessaires depuis la version from.
+// AutoMigrate ne modifie pas un index existant : un index dont les colonnes changent doit
+// être supprimé ici pour être recréé avec sa nouvelle définition.
+func upgrade(db *gorm.DB, from int) error {
+	if from == 0 {
+		return nil // Base vide : AutoMigrate crée directement le schéma courant.
+	}
+
+	if from < 5 {
+		// v5 : la source du clic fait partie de la clé des agrégats journaliers.
+		if err := dropIndexIfExists(db, &models.ClickDailyRollup{}, "idx_click_rollup_key"); err != nil {
+			return err
+		}
+	}
+	if from < 10 {
+		// v10 : la version de destination fait partie de la clé des agrégats journaliers.
+		if err := dropIndexIfExists(db, &models.ClickDailyRollup{}, "idx_click_rollup_key"); err != nil {
+			return err
+		}
+	}
+	if from < 12 {
+		// v12 : la variante A/B fait partie de la clé des agrégats journaliers.
+		if err := dropIndexIfExists(db, &models.ClickDailyRollup{}, "idx_click_rollup_key"); err != nil {
+			return err
+		}
+	}
+	if from < 14 {
+		// v14 : le pays du visiteur fait partie de la clé des agrégats journaliers.
+		if err := dropIndexIfExists(db, &models.ClickDailyRollup{}, "idx_click_rollup_key"); err != nil {
+			return err
+		}
+	}
+	if from < 15 {
+		// v15 : un code court n'est plus unique que pour son domaine.
+		if err := dropIndexIfExists(db, &models.Link{}, "idx_links_shortcode"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfill complète les données des tables créées depuis la version from.
+func backfill(db *gorm.DB, from int) error {
+	if from == 0 {
+		return nil
+	}
+
+	if from < 10 {
+		// v10 : la destination actuelle de chaque lien devient sa version 1.
+		err := db.Exec(`INSERT INTO link_versions (link_id, version, long_url, restored_from, created_by, created_at)
+			SELECT id, 1, long_url, 0, 'migration', created_at FROM links
+			WHERE NOT EXISTS (SELECT 1 FROM link_versions WHERE link_versions.link_id = links.id)`).Error
+		if err != nil {
+			return fmt.Errorf("failed to backfill link versions: %w", err)
+		}
+	}
+	if from < 18 {
+		// v18 : un membre d'espace de travail est qualifié par son type ; les membres existants
+		// étaient des noms de clés d'API.
+		err := db.Exec(`UPDATE workspace_members SET member = 'key:' || member
+			WHERE member NOT LIKE 'key:%' AND member NOT LIKE 'user:%'`).Error
+		if err != nil {
+			return fmt.Errorf("failed to qualify workspace members: %w", err)
+		}
+	}
+	return nil
+}
+
+func dropIndexIfExists(db *gorm.DB, model interface{}, name string) error {
+	if !db.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	if err := db.Migrator().DropIndex(model, name); err != nil {
+		return fmt.Errorf("failed to drop index %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadSchemaVersion lit la version du schéma enregistrée dans la base.
+// Une base qui n'a jamais été migrée retourne 0.
+func ReadSchemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	if err := db.Raw("PRAGMA user_version").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,132 @@
+package geoip
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location est la localisation d'une adresse IP.
+type Location struct {
+	Country string // Code pays ISO 3166-1 alpha-2 (ex: "FR"), vide si inconnu
+	Region  string // Code de la subdivision ISO 3166-2 sans le préfixe pays (ex: "IDF"), vide si inconnu
+}
+
+// record reprend les champs utiles des bases GeoIP2/GeoLite2 Country et City.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// Locator localise les adresses IP à partir d'une base hors ligne au format MaxMind (.mmdb).
+// Un Locator nil ou sans base chargée ne localise rien : Lookup retourne une Location vide.
+type Locator struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+// Open crée un Locator à partir du fichier .mmdb indiqué. Un chemin vide désactive la
+// géolocalisation et retourne nil.
+func Open(path string) (*Locator, error) {
+	if path == "" {
+		return nil, nil
+	}
+	l := &Locator{path: path}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Lookup retourne la localisation d'une adresse IP. Les adresses invalides, privées ou
+// absentes de la base retournent une Location vide.
+func (l *Locator) Lookup(ip string) Location {
+	if l == nil {
+		return Location{}
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}
+	}
+
+	var r record
+	l.mu.RLock()
+	err := l.reader.Lookup(parsed, &r)
+	l.mu.RUnlock()
+	if err != nil {
+		return Location{}
+	}
+
+	loc := Location{Country: r.Country.ISOCode}
+	if len(r.Subdivisions) > 0 {
+		loc.Region = r.Subdivisions[0].ISOCode
+	}
+	return loc
+}
+
+// StartReload surveille le fichier de la base et la recharge dès que sa date de modification
+// change, par exemple après une mise à jour hebdomadaire. Elle est bloquante et doit être
+// appelée dans une goroutine.
+func (l *Locator) StartReload(interval time.Duration) {
+	if l == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(l.path)
+		if err != nil {
+			log.Printf("[GEOIP] Impossible de lire la base '%s' : %v", l.path, err)
+			continue
+		}
+
+		l.mu.RLock()
+		unchanged := info.ModTime().Equal(l.modTime)
+		l.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		if err := l.reload(); err != nil {
+			log.Printf("[GEOIP] ERREUR lors du rechargement de la base : %v", err)
+		}
+	}
+}
+
+// reload ouvre la base et remplace la précédente. L'ancienne base n'est fermée qu'une fois
+// qu'aucune recherche ne l'utilise plus.
+func (l *Locator) reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat GeoIP database: %w", err)
+	}
+	reader, err := maxminddb.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+
+	l.mu.Lock()
+	previous := l.reader
+	l.reader = reader
+	l.modTime = info.ModTime()
+	l.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+	log.Printf("[GEOIP] Base chargée : %s (%s).", l.path, reader.Metadata.DatabaseType)
+	return nil
+}
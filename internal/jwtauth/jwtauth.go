@@ -0,0 +1,304 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Erreurs retournées par la validation d'un jeton.
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpired          = errors.New("token is expired")
+	ErrNotYetValid      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrMissingUser      = errors.New("token has no user claim")
+)
+
+// refetchInterval est le délai minimal entre deux téléchargements du jeu de clés déclenchés
+// par un identifiant de clé inconnu, pour qu'un jeton forgé ne provoque pas une requête par appel.
+const refetchInterval = time.Minute
+
+// Options configure la validation des jetons.
+type Options struct {
+	JWKSURL     string        // URL du jeu de clés publiques du fournisseur d'identité
+	JWKSFile    string        // Fichier local du jeu de clés, utilisé si JWKSURL est vide
+	Issuer      string        // Valeur attendue de la claim "iss", vide pour ne pas la vérifier
+	Audience    string        // Valeur attendue dans la claim "aud", vide pour ne pas la vérifier
+	UserClaim   string        // Claim portant le nom de l'utilisateur, "sub" par défaut
+	AdminClaim  string        // Claim (chaîne ou liste) donnant les droits d'administration
+	AdminValues []string      // Valeurs de AdminClaim qui donnent les droits d'administration
+	Leeway      time.Duration // Tolérance sur les dates exp, nbf et iat
+}
+
+// Identity est l'utilisateur authentifié par un jeton.
+type Identity struct {
+	User  string // Valeur de la claim utilisateur
+	Admin bool   // Vrai si AdminClaim contient l'une des AdminValues
+}
+
+// Verifier valide des jetons JWT signés (RS256/384/512, ES256/384/512) à partir du jeu de
+// clés du fournisseur d'identité. Un Verifier nil ne valide aucun jeton.
+type Verifier struct {
+	opts   Options
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        keySet
+	lastFetched time.Time
+}
+
+// New crée un Verifier et charge le jeu de clés. Sans URL ni fichier de jeu de clés,
+// l'authentification par jeton est désactivée et New retourne nil.
+func New(opts Options) (*Verifier, error) {
+	if opts.JWKSURL == "" && opts.JWKSFile == "" {
+		return nil, nil
+	}
+	if opts.UserClaim == "" {
+		opts.UserClaim = "sub"
+	}
+	v := &Verifier{opts: opts, client: newHTTPClient()}
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify valide un jeton à l'instant now et retourne l'utilisateur qu'il authentifie.
+func (v *Verifier) Verify(token string, now time.Time) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.checkClaims(claims, now); err != nil {
+		return nil, err
+	}
+
+	user, _ := claims[v.opts.UserClaim].(string)
+	if user == "" {
+		return nil, ErrMissingUser
+	}
+	return &Identity{User: user, Admin: v.isAdmin(claims)}, nil
+}
+
+// checkClaims vérifie les dates de validité, l'émetteur et l'audience du jeton.
+func (v *Verifier) checkClaims(claims map[string]any, now time.Time) error {
+	exp, ok := numericDate(claims["exp"])
+	if !ok || !now.Before(exp.Add(v.opts.Leeway)) {
+		return ErrExpired // Un jeton sans date d'expiration est refusé
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.opts.Leeway).Before(nbf) {
+		return ErrNotYetValid
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(v.opts.Leeway).Before(iat) {
+		return ErrNotYetValid
+	}
+	if v.opts.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.opts.Issuer {
+			return ErrInvalidIssuer
+		}
+	}
+	if v.opts.Audience != "" && !containsString(claims["aud"], v.opts.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// isAdmin indique si la claim d'administration contient l'une des valeurs configurées.
+func (v *Verifier) isAdmin(claims map[string]any) bool {
+	if v.opts.AdminClaim == "" {
+		return false
+	}
+	for _, value := range v.opts.AdminValues {
+		if containsString(claims[v.opts.AdminClaim], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// key retourne la clé publique d'identifiant kid. Un identifiant inconnu déclenche un nouveau
+// téléchargement du jeu de clés, le fournisseur ayant pu effectuer une rotation.
+func (v *Verifier) key(kid string) (signingKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.lastFetched) >= refetchInterval
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return signingKey{}, ErrUnknownKey
+	}
+
+	if err := v.refresh(); err != nil {
+		log.Printf("[JWT] ERREUR lors du rechargement des clés : %v", err)
+		return signingKey{}, ErrUnknownKey
+	}
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	v.mu.RUnlock()
+	if !ok {
+		return signingKey{}, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// StartRefresh recharge périodiquement le jeu de clés. Elle est bloquante et doit être
+// appelée dans une goroutine.
+func (v *Verifier) StartRefresh(interval time.Duration) {
+	if v == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := v.refresh(); err != nil {
+			log.Printf("[JWT] ERREUR lors du rechargement des clés : %v", err)
+		}
+	}
+}
+
+// refresh télécharge le jeu de clés et remplace le précédent. En cas d'échec, les clés
+// déjà chargées restent utilisées.
+func (v *Verifier) refresh() error {
+	v.mu.Lock()
+	v.lastFetched = time.Now()
+	v.mu.Unlock()
+
+	keys, err := fetchKeySet(v.client, v.opts.JWKSURL, v.opts.JWKSFile)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	log.Printf("[JWT] %d clé(s) de signature chargée(s).", len(keys))
+	return nil
+}
+
+// verifySignature vérifie la signature d'un jeton selon son algorithme. L'algorithme doit
+// correspondre au type de la clé, à sa courbe pour ECDSA et à l'algorithme de la JWK s'il est
+// précisé : un jeton ne peut pas imposer "none", un HMAC ni un autre algorithme que celui de la clé.
+func verifySignature(alg string, key signingKey, signingInput string, signature []byte) error {
+	if key.alg != "" && key.alg != alg {
+		return ErrUnsupportedAlg
+	}
+	var h hash.Hash
+	var hashID crypto.Hash
+	var curve string
+	switch alg {
+	case "RS256", "ES256":
+		h, hashID, curve = sha256.New(), crypto.SHA256, "P-256"
+	case "RS384", "ES384":
+		h, hashID, curve = sha512.New384(), crypto.SHA384, "P-384"
+	case "RS512", "ES512":
+		h, hashID, curve = sha512.New(), crypto.SHA512, "P-521"
+	default:
+		return ErrUnsupportedAlg
+	}
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			return ErrUnsupportedAlg
+		}
+		if rsa.VerifyPKCS1v15(k, hashID, digest, signature) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" || k.Curve.Params().Name != curve {
+			return ErrUnsupportedAlg
+		}
+		// Une signature ECDSA de JWS est la concaténation de r et s, de taille fixe.
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlg
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDate lit une date JWT, exprimée en secondes depuis l'epoch.
+func numericDate(value any) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// containsString indique si une claim, chaîne ou liste de chaînes, contient want.
+func containsString(claim any, want string) bool {
+	switch c := claim.(type) {
+	case string:
+		return c == want
+	case []any:
+		for _, item := range c {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,387 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testKey est une clé privée de test publiée dans le jeu de clés sous l'identifiant kid.
+type testKey struct {
+	kid  string
+	alg  string // Algorithme annoncé par la JWK, vide pour ne pas le préciser
+	priv crypto.Signer
+}
+
+// jwkJSON retourne la JWK publique de la clé.
+func (k testKey) jwkJSON() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	out := map[string]string{"kid": k.kid, "use": "sig"}
+	if k.alg != "" {
+		out["alg"] = k.alg
+	}
+	switch priv := k.priv.(type) {
+	case *rsa.PrivateKey:
+		out["kty"] = "RSA"
+		out["n"] = b64(priv.N.Bytes())
+		out["e"] = b64(big.NewInt(int64(priv.E)).Bytes())
+	case *ecdsa.PrivateKey:
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		out["kty"] = "EC"
+		out["crv"] = priv.Curve.Params().Name
+		out["x"] = b64(priv.X.FillBytes(make([]byte, size)))
+		out["y"] = b64(priv.Y.FillBytes(make([]byte, size)))
+	}
+	return out
+}
+
+// jwksServer sert un jeu de clés modifiable et compte les téléchargements.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+
+	mu   sync.Mutex
+	keys []testKey
+}
+
+func newJWKSServer(t *testing.T, keys ...testKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		doc := struct {
+			Keys []map[string]string `json:"keys"`
+		}{}
+		for _, k := range s.keys {
+			doc.Keys = append(doc.Keys, k.jwkJSON())
+		}
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...testKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, priv: priv}
+}
+
+func newECKey(t *testing.T, kid string, curve elliptic.Curve) testKey {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, priv: priv}
+}
+
+// sign construit un jeton signé par key avec l'algorithme alg annoncé dans l'en-tête.
+func sign(t *testing.T, key testKey, alg string, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": key.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+
+	var hashID crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hashID = crypto.SHA256
+	case "384":
+		hashID = crypto.SHA384
+	case "512":
+		hashID = crypto.SHA512
+	}
+	h := hashID.New()
+	h.Write([]byte(input))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	switch priv := key.priv.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, priv, hashID, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return input + "." + b64(signature)
+}
+
+var testNow = time.Unix(1_800_000_000, 0)
+
+// validClaims retourne des claims valides à testNow, complétées par extra.
+func validClaims(extra map[string]any) map[string]any {
+	claims := map[string]any{
+		"sub": "alice",
+		"iat": testNow.Add(-time.Minute).Unix(),
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func newTestVerifier(t *testing.T, srv *jwksServer, opts Options) *Verifier {
+	t.Helper()
+	opts.JWKSURL = srv.URL
+	v, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return v
+}
+
+func TestVerifySupportedAlgorithms(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	p256 := newECKey(t, "p256", elliptic.P256())
+	p384 := newECKey(t, "p384", elliptic.P384())
+	p521 := newECKey(t, "p521", elliptic.P521())
+	v := newTestVerifier(t, newJWKSServer(t, rsaKey, p256, p384, p521), Options{})
+
+	cases := []struct {
+		key testKey
+		alg string
+	}{
+		{rsaKey, "RS256"},
+		{rsaKey, "RS384"},
+		{rsaKey, "RS512"},
+		{p256, "ES256"},
+		{p384, "ES384"},
+		{p521, "ES512"},
+	}
+	for _, tc := range cases {
+		identity, err := v.Verify(sign(t, tc.key, tc.alg, validClaims(nil)), testNow)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.alg, err)
+			continue
+		}
+		if identity.User != "alice" || identity.Admin {
+			t.Errorf("%s: unexpected identity %+v", tc.alg, identity)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	v := newTestVerifier(t, newJWKSServer(t, key), Options{})
+
+	token := sign(t, key, "RS256", validClaims(nil))
+	other := sign(t, key, "RS256", validClaims(map[string]any{"sub": "mallory"}))
+	// Claims d'un jeton avec la signature d'un autre.
+	forged := other[:strings.LastIndex(other, ".")] + token[strings.LastIndex(token, "."):]
+	if _, err := v.Verify(forged, testNow); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	if _, err := v.Verify("not-a-token", testNow); !errors.Is(err, ErrMalformedToken) {
+		t.Fatalf("expected ErrMalformedToken, got %v", err)
+	}
+}
+
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	p256 := newECKey(t, "p256", elliptic.P256())
+	p384 := newECKey(t, "p384", elliptic.P384())
+	pinned := newRSAKey(t, "pinned")
+	pinned.alg = "RS512"
+	v := newTestVerifier(t, newJWKSServer(t, rsaKey, p256, p384, pinned), Options{})
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	payload, _ := json.Marshal(validClaims(nil))
+	unsigned := func(alg, kid string) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+		return b64(header) + "." + b64(payload)
+	}
+
+	// HS256 signé avec la clé publique RSA comme secret partagé.
+	hsInput := unsigned("HS256", "rsa")
+	mac := hmac.New(sha256.New, rsaKey.priv.(*rsa.PrivateKey).N.Bytes())
+	mac.Write([]byte(hsInput))
+	hs256 := hsInput + "." + b64(mac.Sum(nil))
+
+	// Signature ES256 d'une clé P-256 présentée sous l'identifiant d'une clé P-384.
+	es256OnP384 := sign(t, testKey{kid: "p384", priv: p256.priv}, "ES256", validClaims(nil))
+
+	cases := map[string]string{
+		"none":               unsigned("none", "rsa") + ".",
+		"HS256":              hs256,
+		"RS256 on EC key":    sign(t, testKey{kid: "p256", priv: rsaKey.priv}, "RS256", validClaims(nil)),
+		"ES256 on RSA key":   sign(t, testKey{kid: "rsa", priv: p256.priv}, "ES256", validClaims(nil)),
+		"ES256 on P-384":     es256OnP384,
+		"ES384 on P-256":     sign(t, p256, "ES384", validClaims(nil)),
+		"RS256 on RS512 key": sign(t, pinned, "RS256", validClaims(nil)),
+	}
+	for name, token := range cases {
+		if _, err := v.Verify(token, testNow); !errors.Is(err, ErrUnsupportedAlg) {
+			t.Errorf("%s: expected ErrUnsupportedAlg, got %v", name, err)
+		}
+	}
+
+	if _, err := v.Verify(sign(t, pinned, "RS512", validClaims(nil)), testNow); err != nil {
+		t.Errorf("RS512 on RS512 key: unexpected error %v", err)
+	}
+}
+
+func TestVerifyTimeClaims(t *testing.T) {
+	key := newECKey(t, "ec", elliptic.P256())
+	v := newTestVerifier(t, newJWKSServer(t, key), Options{Leeway: 30 * time.Second})
+
+	at := func(d time.Duration) int64 { return testNow.Add(d).Unix() }
+	cases := []struct {
+		name   string
+		claims map[string]any
+		want   error
+	}{
+		{"no exp", map[string]any{"sub": "alice"}, ErrExpired},
+		{"expired beyond leeway", map[string]any{"sub": "alice", "exp": at(-time.Minute)}, ErrExpired},
+		{"expired within leeway", map[string]any{"sub": "alice", "exp": at(-10 * time.Second)}, nil},
+		{"nbf beyond leeway", validClaims(map[string]any{"nbf": at(time.Minute)}), ErrNotYetValid},
+		{"nbf within leeway", validClaims(map[string]any{"nbf": at(10 * time.Second)}), nil},
+		{"iat beyond leeway", validClaims(map[string]any{"iat": at(time.Minute)}), ErrNotYetValid},
+		{"iat within leeway", validClaims(map[string]any{"iat": at(10 * time.Second)}), nil},
+	}
+	for _, tc := range cases {
+		_, err := v.Verify(sign(t, key, "ES256", tc.claims), testNow)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestVerifyIssuerAndAudience(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	v := newTestVerifier(t, newJWKSServer(t, key), Options{Issuer: "https://idp.example", Audience: "urlshortener"})
+
+	cases := []struct {
+		name   string
+		claims map[string]any
+		want   error
+	}{
+		{"valid", validClaims(map[string]any{"iss": "https://idp.example", "aud": "urlshortener"}), nil},
+		{"audience list", validClaims(map[string]any{"iss": "https://idp.example", "aud": []string{"other", "urlshortener"}}), nil},
+		{"missing issuer", validClaims(map[string]any{"aud": "urlshortener"}), ErrInvalidIssuer},
+		{"wrong issuer", validClaims(map[string]any{"iss": "https://evil.example", "aud": "urlshortener"}), ErrInvalidIssuer},
+		{"missing audience", validClaims(map[string]any{"iss": "https://idp.example"}), ErrInvalidAudience},
+		{"wrong audience", validClaims(map[string]any{"iss": "https://idp.example", "aud": []string{"other"}}), ErrInvalidAudience},
+	}
+	for _, tc := range cases {
+		_, err := v.Verify(sign(t, key, "RS256", tc.claims), testNow)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestVerifyUserAndAdminClaims(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	v := newTestVerifier(t, newJWKSServer(t, key), Options{
+		UserClaim:   "email",
+		AdminClaim:  "roles",
+		AdminValues: []string{"admin", "superuser"},
+	})
+
+	cases := []struct {
+		name   string
+		claims map[string]any
+		admin  bool
+	}{
+		{"admin string", map[string]any{"roles": "superuser"}, true},
+		{"admin in list", map[string]any{"roles": []string{"user", "admin"}}, true},
+		{"other roles", map[string]any{"roles": []string{"user"}}, false},
+		{"no admin claim", nil, false},
+	}
+	for _, tc := range cases {
+		claims := validClaims(tc.claims)
+		claims["email"] = "alice@example.com"
+		identity, err := v.Verify(sign(t, key, "RS256", claims), testNow)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if identity.User != "alice@example.com" || identity.Admin != tc.admin {
+			t.Errorf("%s: unexpected identity %+v", tc.name, identity)
+		}
+	}
+
+	if _, err := v.Verify(sign(t, key, "RS256", validClaims(nil)), testNow); !errors.Is(err, ErrMissingUser) {
+		t.Errorf("missing user claim: expected ErrMissingUser, got %v", err)
+	}
+}
+
+func TestVerifyRefetchesUnknownKeyOncePerInterval(t *testing.T) {
+	oldKey := newRSAKey(t, "old")
+	newKey := newECKey(t, "new", elliptic.P256())
+	srv := newJWKSServer(t, oldKey)
+	v := newTestVerifier(t, srv, Options{})
+	if got := srv.fetches.Load(); got != 1 {
+		t.Fatalf("expected 1 fetch at startup, got %d", got)
+	}
+
+	// Rotation chez le fournisseur juste après le chargement : tant que l'intervalle n'est
+	// pas écoulé, un identifiant inconnu ne déclenche pas de téléchargement.
+	srv.setKeys(oldKey, newKey)
+	token := sign(t, newKey, "ES256", validClaims(nil))
+	if _, err := v.Verify(token, testNow); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("within interval: expected ErrUnknownKey, got %v", err)
+	}
+	if got := srv.fetches.Load(); got != 1 {
+		t.Fatalf("within interval: expected no refetch, got %d fetches", got)
+	}
+
+	// Intervalle écoulé : le jeu de clés est rechargé et la nouvelle clé acceptée.
+	v.mu.Lock()
+	v.lastFetched = time.Now().Add(-refetchInterval)
+	v.mu.Unlock()
+	if _, err := v.Verify(token, testNow); err != nil {
+		t.Fatalf("after interval: unexpected error %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Fatalf("after interval: expected 2 fetches, got %d", got)
+	}
+
+	// Un identifiant toujours inconnu juste après ce rechargement n'en provoque pas d'autre.
+	unknown := sign(t, newECKey(t, "forged", elliptic.P256()), "ES256", validClaims(nil))
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(unknown, testNow); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("forged kid: expected ErrUnknownKey, got %v", err)
+		}
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Fatalf("forged kid: expected no refetch, got %d fetches", got)
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// États d'un signalement d'abus.
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// AbuseReport est un signalement d'abus déposé par un visiteur sur un lien court.
+// Les signalements ouverts forment la file de modération des administrateurs.
+type AbuseReport struct {
+	ID         uint   `gorm:"primaryKey"`
+	LinkID     uint   `gorm:"index;not null"`
+	Link       Link   `gorm:"foreignKey:LinkID"`
+	Reason     string `gorm:"size:20;not null"` // spam, phishing, malware, illegal ou other
+	Details    string `gorm:"size:1000"`        // Description libre fournie par le visiteur
+	ReporterIP string `gorm:"size:45"`          // Adresse IP du visiteur, pour repérer les signalements abusifs
+	Status     string `gorm:"size:10;not null;default:open;index"`
+	ResolvedBy string `gorm:"size:100"` // Administrateur ayant traité le signalement
+	Resolution string `gorm:"size:10"`  // Action retenue : dismiss, disable ou block
+	ResolvedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// LinkStatusChange conserve l'historique des changements d'état d'un lien :
+// qui l'a désactivé, bloqué ou réactivé, quand et pour quelle raison.
+type LinkStatusChange struct {
+	ID         uint      `gorm:"primaryKey"`
+	LinkID     uint      `gorm:"index;not null"`
+	FromStatus string    `gorm:"size:10;not null"`
+	ToStatus   string    `gorm:"size:10;not null"`
+	Actor      string    `gorm:"size:100;not null"` // Ex: "key:moderation", "cli"
+	Reason     string    `gorm:"size:255"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Actions tracées dans le journal d'audit.
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionDelete   = "delete"
+	AuditActionRollback = "rollback"
+	AuditActionDisable  = "disable"
+	AuditActionBlock    = "block"
+	AuditActionEnable   = "enable"
+
+	// Actions de protection des données personnelles (RGPD)
+	AuditActionEraseVisitor = "erase_visitor"
+	AuditActionPurge        = "purge"
+	AuditActionAnonymize    = "anonymize"
+)
+
+// Origines possibles d'une action tracée.
+const (
+	AuditSourceAPI       = "api"
+	AuditSourceCLI       = "cli"
+	AuditSourceScheduler = "scheduler"
+)
+
+// AuditEvent est une entrée du journal d'audit des modifications de liens.
+// La table est en ajout seul : des triggers SQLite refusent toute modification ou suppression.
+// Les actions RGPD qui ne portent pas sur un lien (effacement d'un visiteur, anonymisation)
+// ont un LinkID nul, un Domain et un ShortCode vides.
+type AuditEvent struct {
+	ID        uint      `gorm:"primaryKey"`
+	Action    string    `gorm:"size:20;not null;index"`
+	LinkID    uint      `gorm:"index;not null"`
+	Domain    string    `gorm:"size:255;not null;default:''"` // Domaine court du lien, vide pour le domaine principal
+	ShortCode string    `gorm:"size:10;index;not null"`       // Conservé pour retrouver un lien supprimé
+	Actor     string    `gorm:"size:100;not null;index"`      // Ex: "key:marketing", "ip:203.0.113.7", "cli:alice"
+	Source    string    `gorm:"size:10;not null"`             // api, cli ou scheduler
+	Before    string    `gorm:"type:text"`                    // État JSON du lien avant l'action, vide pour une création
+	After     string    `gorm:"type:text"`                    // État JSON du lien après l'action, vide pour une suppression
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
@@ -9,15 +9,28 @@ type Click struct {
 	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
 	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
 	Timestamp time.Time // Horodatage précis du clic
-	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
-	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	UserAgent string    `gorm:"size:255"`           // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
+	IPAddress string    `gorm:"size:50"`            // Adresse IP de l'utilisateur
+	Referrer  string    `gorm:"size:255"`           // Domaine de la page d'origine (en-tête Referer), vide si absent
+	Source    string    `gorm:"size:20"`            // Canal d'origine déclaré par le marqueur ?src= (ex: "qr"), vide sinon
+	Version   int       `gorm:"not null;default:0"` // Version de la destination du lien au moment du clic
+	Variant   string    `gorm:"size:50"`            // Variante A/B servie, vide si le lien n'a pas de variantes
+	Country   string    `gorm:"size:2"`             // Pays du visiteur (ISO 3166-1 alpha-2) d'après la base GeoIP, vide si inconnu
+	Region    string    `gorm:"size:10"`            // Région du visiteur (subdivision ISO 3166-2), vide si inconnue
 }
 
 type ClickEvent struct {
-	LinkID    uint
-	Timestamp time.Time
-	UserAgent string
-	IpAddress string
+	LinkID      uint
+	Domain      string // Domaine et code court du lien, transmis aux destinations des clics
+	ShortCode   string
+	WorkspaceID uint // Espace de travail du lien, pour le flux de clics en direct de l'espace
+	Timestamp   time.Time
+	UserAgent   string
+	IpAddress   string
+	Referrer    string
+	Source      string
+	Version     int    // Version de la destination servie
+	Variant     string // Variante A/B servie
 }
 
 // ClickEvent représente un événement de clic brut, destiné à être passé via un channel
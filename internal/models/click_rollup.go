@@ -0,0 +1,64 @@
+package models
+
+// ClickDailyRollup agrège les clics bruts d'un lien pour une journée donnée.
+// Les clics plus anciens que la durée de rétention sont regroupés dans cette table
+// puis supprimés de 'clicks'. Une ligne correspond à une combinaison
+// (lien, jour, referrer, appareil, source, version de destination, variante, pays) et porte le nombre
+// de clics correspondant.
+type ClickDailyRollup struct {
+	ID       uint   `gorm:"primaryKey"`
+	LinkID   uint   `gorm:"not null;uniqueIndex:idx_click_rollup_key,priority:1"`
+	Day      string `gorm:"size:10;not null;uniqueIndex:idx_click_rollup_key,priority:2"` // Jour UTC au format AAAA-MM-JJ
+	Referrer string `gorm:"size:255;not null;default:'';uniqueIndex:idx_click_rollup_key,priority:3"`
+	Device   string `gorm:"size:20;not null;default:'';uniqueIndex:idx_click_rollup_key,priority:4"`
+	Source   string `gorm:"size:20;not null;default:'';uniqueIndex:idx_click_rollup_key,priority:5"`
+	Version  int    `gorm:"not null;default:0;uniqueIndex:idx_click_rollup_key,priority:6"`
+	Variant  string `gorm:"size:50;not null;default:'';uniqueIndex:idx_click_rollup_key,priority:7"`
+	Country  string `gorm:"size:2;not null;default:'';uniqueIndex:idx_click_rollup_key,priority:8"` // La région n'est pas conservée
+	Count    int    `gorm:"not null"`
+}
+
+// DailyClickCount est le nombre de clics d'un lien pour un jour donné,
+// toutes sources confondues (clics bruts et agrégats).
+type DailyClickCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// SourceClickCount est le nombre de clics d'un lien pour un canal d'origine ("" pour un accès direct).
+type SourceClickCount struct {
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// VersionClickCount est le nombre de clics d'un lien reçus pendant qu'une version de sa
+// destination était active (0 pour les clics antérieurs à l'historique des versions).
+type VersionClickCount struct {
+	Version int `json:"version"`
+	Count   int `json:"count"`
+}
+
+// VariantClickCount est le nombre de clics d'un lien servis par une variante A/B
+// ("" pour les clics servis par la destination principale).
+type VariantClickCount struct {
+	Variant string `json:"variant"`
+	Count   int    `json:"count"`
+}
+
+// CountryClickCount est le nombre de clics d'un lien venant d'un pays ("" si inconnu).
+type CountryClickCount struct {
+	Country string `json:"country"`
+	Count   int    `json:"count"`
+}
+
+// ReferrerClickCount est le nombre de clics d'un lien venant d'un domaine référent ("" pour un accès direct).
+type ReferrerClickCount struct {
+	Referrer string `json:"referrer"`
+	Count    int    `json:"count"`
+}
+
+// DeviceClickCount est le nombre de clics d'un lien par type d'appareil (desktop, mobile, tablet, bot ou unknown).
+type DeviceClickCount struct {
+	Device string `json:"device"`
+	Count  int    `json:"count"`
+}
@@ -1,17 +1,96 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// États possibles d'un lien. Un lien désactivé répond 410, un lien bloqué pour abus répond 451.
+const (
+	LinkStatusActive   = "active"
+	LinkStatusDisabled = "disabled"
+	LinkStatusBlocked  = "blocked"
+)
 
 type Link struct {
-	ID        uint      `gorm:"primaryKey"`
-	Shortcode string    `gorm:"size:10;uniqueIndex;not null"`
-	LongURL   string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
+	ID              uint            `gorm:"primaryKey"`
+	Domain          string          `gorm:"size:255;not null;default:'';uniqueIndex:idx_link_domain_code,priority:1"` // Domaine court du lien, vide pour le domaine principal
+	Shortcode       string          `gorm:"size:10;not null;uniqueIndex:idx_link_domain_code,priority:2"`
+	LongURL         string          `gorm:"not null"`
+	RedirectType    int             `gorm:"not null;default:0"` // 301, 302, 307 ou 308. 0 : utilise la valeur par défaut du serveur
+	ExpiresAt       *time.Time      // Date d'expiration optionnelle, le lien répond 410 au-delà
+	ActiveFrom      *time.Time      // Date de mise en ligne optionnelle, le lien affiche "pas encore disponible" avant
+	NextChangeAt    *time.Time      `gorm:"index"`                                 // Date du prochain changement de destination programmé, nil s'il n'y en a pas
+	TrackEveryClick bool            `gorm:"not null;default:false"`                // Interdit la mise en cache de la redirection pour ne perdre aucun clic
+	ForwardQuery    bool            `gorm:"not null;default:false"`                // Transmet les paramètres de l'URL courte à l'URL longue
+	PasswordHash    string          `gorm:"size:100"`                              // Hash bcrypt du mot de passe protégeant le lien, vide si le lien est public
+	SignedOnly      bool            `gorm:"not null;default:false"`                // N'accepte que les URLs signées (?exp=...&sig=...)
+	Status          string          `gorm:"size:10;not null;default:active;index"` // active, disabled ou blocked
+	WorkspaceID     uint            `gorm:"not null;default:0;index"`              // Espace de travail propriétaire, 0 pour un lien partagé
+	Version         int             `gorm:"not null;default:1"`                    // Numéro de la version de destination active
+	Versions        []LinkVersion   `gorm:"foreignKey:LinkID"`
+	Variants        []LinkVariant   `gorm:"foreignKey:LinkID"`
+	StickyVariants  bool            `gorm:"not null;default:false"` // Un visiteur retrouve la même variante grâce à un cookie
+	Rules           []TargetingRule `gorm:"foreignKey:LinkID"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime"`
+	DeletedAt       gorm.DeletedAt  `gorm:"index"` // Suppression logique : le code court n'est jamais réattribué
+}
+
+// IsProtected indique si l'accès au lien exige un mot de passe.
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// IsActive indique si le lien peut être servi, c'est-à-dire s'il n'a été ni désactivé ni bloqué.
+func (l *Link) IsActive() bool {
+	return l.Status == "" || l.Status == LinkStatusActive
+}
+
+// IsNotYetActive indique si la date de mise en ligne du lien n'est pas encore atteinte.
+func (l *Link) IsNotYetActive(now time.Time) bool {
+	return l.ActiveFrom != nil && now.Before(*l.ActiveFrom)
+}
+
+// HasDueChange indique si un changement de destination programmé est arrivé à échéance.
+func (l *Link) HasDueChange(now time.Time) bool {
+	return l.NextChangeAt != nil && !now.Before(*l.NextChangeAt)
+}
+
+// HasVariants indique si le trafic du lien est réparti entre plusieurs destinations.
+func (l *Link) HasVariants() bool {
+	return len(l.Variants) > 0
+}
+
+// HasRules indique si la destination du lien dépend du visiteur (règles de ciblage).
+func (l *Link) HasRules() bool {
+	return len(l.Rules) > 0
+}
+
+// IsExpired indique si le lien a dépassé sa date d'expiration à l'instant donné.
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
 }
 
 // Link représente un lien raccourci dans la base de données.
 // Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
 // ID qui est une primaryKey
-// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 10 caractères
+// Domain : domaine court auquel le lien appartient, chaque domaine a son propre espace de codes
+// Shortcode : doit être unique pour son domaine, indexé pour des recherches rapide (voir doc), taille max 10 caractères
 // LongURL : doit pas être null
+// RedirectType : code HTTP de redirection propre au lien
+// ExpiresAt : date au-delà de laquelle le lien n'est plus servi (fin de la fenêtre d'activation)
+// ActiveFrom : date avant laquelle le lien n'est pas encore servi (début de la fenêtre d'activation)
+// NextChangeAt : échéance du prochain ScheduledChange en attente, pour éviter une requête par redirection
+// TrackEveryClick : empêche les navigateurs de mettre la redirection en cache
+// ForwardQuery : ajoute les paramètres de la requête (?ref=...) à l'URL longue lors de la redirection
+// PasswordHash : hash lent (bcrypt) du mot de passe, jamais le mot de passe en clair
+// SignedOnly : le lien ne répond qu'aux URLs signées et non expirées
+// Status : état de modération du lien (actif, désactivé ou bloqué)
+// WorkspaceID : espace de travail dont seuls les membres accèdent au lien, 0 si le lien n'appartient à aucun espace
+// Version : version active de la destination, voir LinkVersion
+// Variants : destinations pondérées entre lesquelles le trafic est réparti (test A/B)
+// StickyVariants : mémorise la variante servie à chaque visiteur dans un cookie
+// Rules : règles de ciblage (système, appareil, langue, pays) évaluées avant la destination par défaut
 // CreateAt : Horodatage de la créatino du lien
+// DeletedAt : date de suppression, les liens supprimés sont exclus des requêtes par GORM
@@ -0,0 +1,12 @@
+package models
+
+// LinkVariant est une destination alternative d'un lien pour les tests A/B. Lorsqu'un lien
+// possède des variantes, chaque redirection en choisit une au hasard, proportionnellement à
+// son poids ; l'URL longue du lien n'est alors plus utilisée.
+type LinkVariant struct {
+	ID      uint   `gorm:"primaryKey"`
+	LinkID  uint   `gorm:"not null;uniqueIndex:idx_link_variant,priority:1"`
+	Name    string `gorm:"size:50;not null;uniqueIndex:idx_link_variant,priority:2"` // Nom de la variante, enregistré sur chaque clic
+	LongURL string `gorm:"not null"`
+	Weight  int    `gorm:"not null;default:1"` // Poids relatif de la variante dans la répartition du trafic
+}
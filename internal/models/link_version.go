@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LinkVersion est une version de la destination d'un lien. Chaque changement d'URL longue
+// crée une nouvelle version ; un retour en arrière crée lui aussi une nouvelle version,
+// copie de l'ancienne, afin que l'historique ne soit jamais réécrit.
+type LinkVersion struct {
+	ID           uint      `gorm:"primaryKey"`
+	LinkID       uint      `gorm:"not null;uniqueIndex:idx_link_version,priority:1"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_link_version,priority:2"`
+	LongURL      string    `gorm:"not null"`
+	RestoredFrom int       `gorm:"not null;default:0"` // Version restaurée par un retour en arrière, 0 sinon
+	CreatedBy    string    `gorm:"size:100"`           // Auteur du changement, comme dans le journal d'audit
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// États d'un changement de destination programmé.
+const (
+	ScheduleStatusPending   = "pending"
+	ScheduleStatusApplied   = "applied"
+	ScheduleStatusFailed    = "failed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// ScheduledChange est un changement de destination programmé pour un lien : à la date At,
+// l'URL longue du lien devient LongURL (ce qui crée une nouvelle version du lien).
+type ScheduledChange struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"index;not null"`
+	At        time.Time `gorm:"index;not null"`
+	LongURL   string    `gorm:"not null"`
+	Status    string    `gorm:"size:10;not null;default:pending;index"`
+	Error     string    `gorm:"size:255"` // Raison de l'échec, si la destination a été refusée à l'échéance
+	CreatedBy string    `gorm:"size:100"`
+	AppliedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
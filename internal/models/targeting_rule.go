@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TargetingRule redirige vers une destination dédiée les visiteurs qui correspondent à tous
+// ses critères renseignés (un critère vide correspond à tout le monde). Les règles d'un lien
+// sont évaluées par priorité croissante ; sans règle correspondante, la destination par
+// défaut du lien est servie.
+type TargetingRule struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"not null;index"`
+	Priority  int       `gorm:"not null;default:0"` // Les règles de priorité la plus faible sont évaluées en premier
+	OS        string    `gorm:"size:20"`            // Système d'exploitation (useragent.OSiOS, ...), vide pour tous
+	Device    string    `gorm:"size:20"`            // Classe d'appareil (useragent.DeviceMobile, ...), vide pour toutes
+	Language  string    `gorm:"size:20"`            // Langue préférée du visiteur (ex: "fr" ou "fr-ca"), vide pour toutes
+	Country   string    `gorm:"size:2"`             // Pays du visiteur d'après la base GeoIP (ex: "FR"), vide pour tous
+	LongURL   string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Événements auxquels un webhook peut s'abonner.
+const (
+	WebhookEventLinkCreated       = "link.created"
+	WebhookEventLinkUpdated       = "link.updated"
+	WebhookEventLinkDeleted       = "link.deleted"
+	WebhookEventClickRecorded     = "click.recorded"
+	WebhookEventLinkHealthChanged = "link.health_changed"
+)
+
+// WebhookEvents liste les événements connus, dans l'ordre de leur documentation.
+var WebhookEvents = []string{
+	WebhookEventLinkCreated,
+	WebhookEventLinkUpdated,
+	WebhookEventLinkDeleted,
+	WebhookEventClickRecorded,
+	WebhookEventLinkHealthChanged,
+}
+
+// Webhook est un abonnement d'une URL externe à des événements. Chaque envoi est signé
+// par HMAC-SHA256 avec Secret.
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey"`
+	URL       string    `gorm:"not null"`
+	Secret    string    `gorm:"size:100;not null"`
+	Events    string    `gorm:"not null"` // Événements abonnés, séparés par des virgules
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// WebhookDelivery est un envoi d'événement en attente. Il est retenté avec un délai
+// croissant jusqu'à sa réussite, ou déplacé dans les lettres mortes (WebhookDeadLetter)
+// après le nombre maximal de tentatives.
+type WebhookDelivery struct {
+	ID            uint      `gorm:"primaryKey"`
+	WebhookID     uint      `gorm:"index;not null"`
+	EventID       string    `gorm:"size:32;not null"` // Identifiant de l'événement, conservé d'une tentative à l'autre
+	Event         string    `gorm:"size:30;not null"`
+	Payload       string    `gorm:"not null"` // Corps JSON envoyé, identique à chaque tentative
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"index;not null"`
+	LastError     string    `gorm:"size:255"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// WebhookDeadLetter est un envoi abandonné après le nombre maximal de tentatives.
+// Il peut être renvoyé, ce qui le replace dans la file des envois.
+type WebhookDeadLetter struct {
+	ID        uint      `gorm:"primaryKey"`
+	WebhookID uint      `gorm:"index;not null"`
+	EventID   string    `gorm:"size:32;not null"`
+	Event     string    `gorm:"size:30;not null"`
+	Payload   string    `gorm:"not null"`
+	Attempts  int       `gorm:"not null"`
+	LastError string    `gorm:"size:255"`
+	FailedAt  time.Time `gorm:"index;not null"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Rôles d'un membre dans un espace de travail, du plus au moins privilégié.
+// owner gère les membres, editor crée et modifie les liens, viewer consulte liens et statistiques.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleViewer = "viewer"
+)
+
+// Workspace est l'espace de travail d'une équipe. Les liens créés au nom d'un espace
+// ne sont visibles et modifiables que par ses membres.
+type Workspace struct {
+	ID        uint              `gorm:"primaryKey"`
+	Slug      string            `gorm:"size:50;uniqueIndex;not null"` // Identifiant présenté dans l'en-tête X-Workspace
+	Name      string            `gorm:"size:100;not null"`
+	Members   []WorkspaceMember `gorm:"foreignKey:WorkspaceID"`
+	CreatedAt time.Time         `gorm:"autoCreateTime"`
+}
+
+// WorkspaceMember donne un rôle dans un espace de travail à un client de l'API,
+// identifié par "key:<nom>" pour une clé d'API (auth.api_keys) ou "user:<utilisateur>"
+// pour un jeton JWT (auth.jwt).
+type WorkspaceMember struct {
+	ID          uint      `gorm:"primaryKey"`
+	WorkspaceID uint      `gorm:"not null;uniqueIndex:idx_workspace_member,priority:1"`
+	Member      string    `gorm:"size:100;not null;uniqueIndex:idx_workspace_member,priority:2"` // key:<nom> ou user:<utilisateur>
+	Role        string    `gorm:"size:10;not null"`                                              // owner, editor ou viewer
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
@@ -6,22 +6,31 @@ import (
 	"sync"
 	"time"
 
-	_ "github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/netutil"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
 )
 
 type UrlMonitor struct {
 	linkRepo    repository.LinkRepository
 	interval    time.Duration
+	client      *http.Client
 	knownStates map[uint]bool
 	mu          sync.Mutex
+	webhooks    *services.WebhookService
 }
 
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+// NewUrlMonitor crée un moniteur d'URLs. client doit être un client durci
+// (voir netutil.NewSafeClient) : les URLs surveillées sont fournies par les utilisateurs.
+// webhooks reçoit l'événement link.health_changed à chaque changement d'état ; il peut être nil.
+func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration, client *http.Client, webhooks *services.WebhookService) *UrlMonitor {
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
 		interval:    interval,
+		client:      client,
 		knownStates: make(map[uint]bool),
+		webhooks:    webhooks,
 	}
 }
 
@@ -70,6 +79,13 @@ func (m *UrlMonitor) checkUrls() {
 		if currentState != previousState {
 			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
 				link.Shortcode, link.LongURL, formatState(previousState), formatState(currentState))
+			m.webhooks.Publish(models.WebhookEventLinkHealthChanged, services.HealthEventData{
+				LinkID:     link.ID,
+				Domain:     link.Domain,
+				ShortCode:  link.Shortcode,
+				LongURL:    link.LongURL,
+				Accessible: currentState,
+			})
 		}
 
 		if !currentState && previousState {
@@ -83,16 +99,12 @@ func (m *UrlMonitor) checkUrls() {
 }
 
 func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	client := http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	resp, err := client.Head(url)
+	resp, err := m.client.Head(url)
 	if err != nil {
 		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
 		return false
 	}
-	defer resp.Body.Close()
+	defer netutil.DrainBody(resp.Body)
 
 	// Déterminer l'accessibilité basée sur le code de statut HTTP.
 	return resp.StatusCode >= 200 && resp.StatusCode < 400 // Codes 2xx ou 3xx
@@ -0,0 +1,81 @@
+package netutil
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// Modes de conservation des adresses IP des visiteurs.
+const (
+	IPModeFull      = "full"      // Adresse complète
+	IPModeTruncated = "truncated" // Réseau /24 en IPv4, /48 en IPv6 : la géolocalisation reste possible
+	IPModeHashed    = "hashed"    // HMAC de l'adresse : permet de compter les visiteurs distincts sans la conserver
+	IPModeNone      = "none"      // Aucune adresse
+)
+
+// IPAnonymizer applique le mode de conservation configuré aux adresses IP avant leur enregistrement.
+type IPAnonymizer struct {
+	mode   string
+	secret []byte
+}
+
+// NewIPAnonymizer crée un IPAnonymizer pour le mode donné ("" équivaut à "full").
+// En mode "hashed", secret sert de clé au HMAC ; vide, une clé aléatoire est générée et les
+// empreintes changent alors à chaque redémarrage.
+func NewIPAnonymizer(mode, secret string) (*IPAnonymizer, error) {
+	if mode == "" {
+		mode = IPModeFull
+	}
+	switch mode {
+	case IPModeFull, IPModeTruncated, IPModeNone:
+		return &IPAnonymizer{mode: mode}, nil
+	case IPModeHashed:
+		key := []byte(secret)
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate IP hash key: %w", err)
+			}
+		}
+		return &IPAnonymizer{mode: mode, secret: key}, nil
+	}
+	return nil, fmt.Errorf("unknown IP mode %q (expected full, truncated, hashed or none)", mode)
+}
+
+// Mode retourne le mode de conservation appliqué.
+func (a *IPAnonymizer) Mode() string {
+	if a == nil {
+		return IPModeFull
+	}
+	return a.mode
+}
+
+// Anonymize retourne la forme de l'adresse ip à enregistrer selon le mode configuré.
+// Un IPAnonymizer nil conserve l'adresse complète.
+func (a *IPAnonymizer) Anonymize(ip string) string {
+	if a == nil || ip == "" {
+		return ip
+	}
+	switch a.mode {
+	case IPModeNone:
+		return ""
+	case IPModeTruncated:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return ""
+		}
+		if v4 := parsed.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return parsed.Mask(net.CIDRMask(48, 128)).String()
+	case IPModeHashed:
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(ip))
+		return hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+	return ip
+}
@@ -0,0 +1,146 @@
+package netutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBlockedAddress est retournée lorsqu'une connexion sortante vise une adresse non publique.
+	ErrBlockedAddress = errors.New("connection to non-public address blocked")
+	// ErrTooManyRedirects est retournée lorsque la limite de redirections est dépassée.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrResponseTooLarge est retournée lorsque le corps d'une réponse dépasse la taille autorisée.
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+// SafeClientOptions paramètre le client HTTP sortant durci.
+type SafeClientOptions struct {
+	Timeout          time.Duration
+	MaxRedirects     int
+	MaxResponseBytes int64
+	UserAgent        string
+	// AllowAddress décide si une connexion vers l'adresse IP résolue est autorisée.
+	// Nil applique IsPublicIP ; les tests l'utilisent pour joindre un serveur local.
+	AllowAddress func(ip net.IP) bool
+}
+
+// NewSafeClient crée un client HTTP protégé contre les attaques SSRF.
+// L'adresse est vérifiée au moment de la connexion, après la résolution DNS : un nom
+// d'hôte qui résout vers une adresse privée, de bouclage ou de métadonnées est refusé,
+// y compris lors de chaque redirection. Les proxys d'environnement sont ignorés pour
+// que la vérification porte bien sur la destination réelle.
+func NewSafeClient(opts SafeClientOptions) *http.Client {
+	allow := opts.AllowAddress
+	if allow == nil {
+		allow = IsPublicIP
+	}
+	dialer := &net.Dialer{
+		Timeout:   opts.Timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   controlAllowed(allow),
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: opts.Timeout,
+	}
+
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &safeRoundTripper{
+			next:      transport,
+			userAgent: opts.UserAgent,
+			maxBytes:  opts.MaxResponseBytes,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// controlAllowed retourne la fonction appelée par le dialer juste avant chaque connexion,
+// avec l'adresse IP déjà résolue : c'est le seul endroit où la vérification ne peut pas
+// être contournée par un changement de résolution DNS (DNS rebinding).
+func controlAllowed(allow func(net.IP) bool) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if !allow(net.ParseIP(host)) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+		}
+		return nil
+	}
+}
+
+// safeRoundTripper ajoute le User-Agent identifiant le service et limite la taille des réponses.
+type safeRoundTripper struct {
+	next      http.RoundTripper
+	userAgent string
+	maxBytes  int64
+}
+
+func (t *safeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || t.maxBytes <= 0 {
+		return resp, err
+	}
+
+	if resp.ContentLength > t.maxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes announced", ErrResponseTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxBytes}
+	return resp, nil
+}
+
+// limitedBody renvoie ErrResponseTooLarge dès que plus de remaining octets ont été lus.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Lit un octet de plus que la limite pour détecter un dépassement.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// DrainBody lit puis ferme le corps d'une réponse afin de réutiliser la connexion.
+func DrainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, body)
+	body.Close()
+}
@@ -0,0 +1,152 @@
+package netutil
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allowLoopbackOnly autorise le serveur de test local et refuse toute autre adresse,
+// ce qui permet de vérifier le blocage sans joindre de vraie adresse privée.
+func allowLoopbackOnly(ip net.IP) bool {
+	return ip.Equal(net.IPv4(127, 0, 0, 1))
+}
+
+func newTestClient(opts SafeClientOptions) *http.Client {
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.AllowAddress == nil {
+		opts.AllowAddress = allowLoopbackOnly
+	}
+	return NewSafeClient(opts)
+}
+
+func TestSafeClientBlocksLoopbackByDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := NewSafeClient(SafeClientOptions{Timeout: 2 * time.Second})
+	_, err := client.Get(srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress, got %v", err)
+	}
+}
+
+func TestSafeClientBlocksPrivateTarget(t *testing.T) {
+	client := newTestClient(SafeClientOptions{})
+	_, err := client.Get("http://10.0.0.1/")
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress, got %v", err)
+	}
+}
+
+func TestSafeClientBlocksPrivateRedirectHop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://192.168.1.1/admin", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(SafeClientOptions{MaxRedirects: 5})
+	_, err := client.Get(srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress, got %v", err)
+	}
+}
+
+func TestSafeClientMaxRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/3" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next := map[string]string{"/": "/1", "/1": "/2", "/2": "/3"}[r.URL.Path]
+		http.Redirect(w, r, next, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := newTestClient(SafeClientOptions{MaxRedirects: 3}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("3 redirects with MaxRedirects=3: unexpected error %v", err)
+	}
+	DrainBody(resp.Body)
+
+	_, err = newTestClient(SafeClientOptions{MaxRedirects: 2}).Get(srv.URL)
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("3 redirects with MaxRedirects=2: expected ErrTooManyRedirects, got %v", err)
+	}
+}
+
+func TestSafeClientMaxResponseBytes(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("chunked") {
+			// Sans Content-Length, la limite n'est détectée qu'à la lecture.
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(SafeClientOptions{MaxResponseBytes: 10})
+
+	// Taille annoncée par Content-Length : refus immédiat.
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("announced size: expected ErrResponseTooLarge, got %v", err)
+	}
+
+	// Taille inconnue : la lecture s'arrête à la limite.
+	resp, err := client.Get(srv.URL + "?chunked=1")
+	if err != nil {
+		t.Fatalf("chunked: unexpected error %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("chunked: expected ErrResponseTooLarge, got %v", err)
+	}
+	if len(data) != 10 {
+		t.Fatalf("chunked: expected 10 bytes before the limit, got %d", len(data))
+	}
+
+	// Une réponse dans la limite est lue entièrement.
+	resp, err = newTestClient(SafeClientOptions{MaxResponseBytes: 100}).Get(srv.URL + "?chunked=1")
+	if err != nil {
+		t.Fatalf("within limit: unexpected error %v", err)
+	}
+	defer resp.Body.Close()
+	if data, err := io.ReadAll(resp.Body); err != nil || string(data) != body {
+		t.Fatalf("within limit: got %d bytes, err %v", len(data), err)
+	}
+}
+
+func TestSafeClientUserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.UserAgent())
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/next", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := newTestClient(SafeClientOptions{MaxRedirects: 1, UserAgent: "urlshortener-test/1.0"}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	DrainBody(resp.Body)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(got))
+	}
+	for i, ua := range got {
+		if ua != "urlshortener-test/1.0" {
+			t.Errorf("request %d: User-Agent %q", i, ua)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package netutil
+
+import "net"
+
+// blockedNetworks sont les plages d'adresses qui ne doivent jamais être la cible d'un lien
+// ou d'une requête sortante : réseaux privés, partagés (CGNAT), de documentation, etc.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",          // "Ce réseau"
+	"10.0.0.0/8",         // Privé (RFC 1918)
+	"100.64.0.0/10",      // Espace partagé CGNAT (RFC 6598)
+	"172.16.0.0/12",      // Privé (RFC 1918)
+	"192.0.0.0/24",       // Affectations de protocole IETF
+	"192.168.0.0/16",     // Privé (RFC 1918)
+	"198.18.0.0/15",      // Tests de performance (RFC 2544)
+	"240.0.0.0/4",        // Réservé
+	"255.255.255.255/32", // Diffusion
+	"64:ff9b:1::/48",     // Traduction NAT64 locale
+	"fc00::/7",           // Adresses locales uniques IPv6
+)
+
+// IsPublicIP indique si ip est une adresse unicast publique. Les adresses de bouclage,
+// link-local (dont le service de métadonnées cloud 169.254.169.254), multicast, non
+// spécifiées et celles des plages de blockedNetworks sont refusées.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	// Une adresse IPv4 encapsulée dans IPv6 (::ffff:10.0.0.1) est évaluée comme IPv4.
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || ip.IsPrivate() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,408 @@
+// Package qrcode génère des QR codes (mode octet, versions 1 à 40) sans dépendance externe.
+// L'implémentation suit la norme ISO/IEC 18004 : encodage des données, correction d'erreurs
+// Reed-Solomon, placement des motifs fixes et choix du masque de plus faible pénalité.
+package qrcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Level est le niveau de correction d'erreurs d'un QR code.
+type Level int
+
+// Niveaux de correction d'erreurs, du moins robuste (L, ~7%) au plus robuste (H, ~30%).
+const (
+	Low Level = iota
+	Medium
+	Quartile
+	High
+)
+
+// formatBits sont les bits de format associés à chaque niveau (ordre L, M, Q, H).
+var formatBits = [4]int{1, 0, 3, 2}
+
+// ErrTooLong est retournée lorsque le contenu ne tient pas dans un QR code de version 40.
+var ErrTooLong = errors.New("qrcode: content too long")
+
+// ParseLevel convertit "L", "M", "Q" ou "H" (insensible à la casse) en Level.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return Low, nil
+	case "M", "":
+		return Medium, nil
+	case "Q":
+		return Quartile, nil
+	case "H":
+		return High, nil
+	}
+	return 0, errors.New("qrcode: error correction level must be L, M, Q or H")
+}
+
+// Code est un QR code encodé : une grille carrée de modules sombres (true) ou clairs (false).
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+}
+
+// Dark indique si le module en colonne x et ligne y est sombre.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode encode content en mode octet dans le plus petit QR code possible au niveau donné.
+func Encode(content string, level Level) (*Code, error) {
+	data := []byte(content)
+
+	version := 0
+	for v := 1; v <= 40; v++ {
+		capacityBits := numDataCodewords(v, level) * 8
+		if 4+charCountBits(v)+len(data)*8 <= capacityBits {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	// Segment en mode octet : indicateur de mode, longueur puis données.
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	// Terminateur, alignement sur l'octet puis octets de bourrage alternés.
+	capacityBits := numDataCodewords(version, level) * 8
+	bb.append(0, min(4, capacityBits-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+
+	q := newGrid(version)
+	q.drawFunctionPatterns(level)
+	q.drawCodewords(addECCAndInterleave(codewords, version, level))
+
+	// Choisit le masque qui produit la pénalité la plus faible.
+	bestMask, minPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(level, mask)
+		if penalty := q.penaltyScore(); minPenalty < 0 || penalty < minPenalty {
+			bestMask, minPenalty = mask, penalty
+		}
+		q.applyMask(mask) // Le masque est un XOR : l'appliquer une seconde fois l'annule.
+	}
+	q.applyMask(bestMask)
+	q.drawFormatBits(level, bestMask)
+
+	return &Code{Version: version, Size: q.size, modules: q.modules}, nil
+}
+
+// charCountBits retourne la taille du champ de longueur en mode octet pour une version.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// bitBuffer est une suite de bits ajoutés du poids fort au poids faible.
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (value>>uint(i))&1 != 0)
+	}
+}
+
+// grid est la matrice en cours de construction, avec le marquage des modules fixes.
+type grid struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newGrid(version int) *grid {
+	size := version*4 + 17
+	g := &grid{version: version, size: size}
+	g.modules = make([][]bool, size)
+	g.isFunction = make([][]bool, size)
+	for i := range g.modules {
+		g.modules[i] = make([]bool, size)
+		g.isFunction[i] = make([]bool, size)
+	}
+	return g
+}
+
+func (g *grid) setFunction(x, y int, dark bool) {
+	g.modules[y][x] = dark
+	g.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns place les motifs de synchronisation, de position, d'alignement,
+// ainsi que des informations de format provisoires et les informations de version.
+func (g *grid) drawFunctionPatterns(level Level) {
+	for i := 0; i < g.size; i++ {
+		g.setFunction(6, i, i%2 == 0)
+		g.setFunction(i, 6, i%2 == 0)
+	}
+
+	g.drawFinderPattern(3, 3)
+	g.drawFinderPattern(g.size-4, 3)
+	g.drawFinderPattern(3, g.size-4)
+
+	positions := alignmentPatternPositions(g.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Les motifs d'alignement ne recouvrent jamais les motifs de position.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			g.drawAlignmentPattern(x, y)
+		}
+	}
+
+	g.drawFormatBits(level, 0)
+	g.drawVersion()
+}
+
+func (g *grid) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			dist := max(abs(dx), abs(dy))
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < g.size && yy >= 0 && yy < g.size {
+				g.setFunction(xx, yy, dist != 2 && dist != 4)
+			}
+		}
+	}
+}
+
+func (g *grid) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			g.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits écrit les deux copies des 15 bits de format (niveau et masque).
+func (g *grid) drawFormatBits(level Level, mask int) {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		g.setFunction(8, i, bit(bits, i))
+	}
+	g.setFunction(8, 7, bit(bits, 6))
+	g.setFunction(8, 8, bit(bits, 7))
+	g.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		g.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		g.setFunction(g.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		g.setFunction(8, g.size-15+i, bit(bits, i))
+	}
+	g.setFunction(8, g.size-8, true) // Module toujours sombre
+}
+
+// drawVersion écrit les deux blocs d'informations de version (versions 7 et plus).
+func (g *grid) drawVersion() {
+	if g.version < 7 {
+		return
+	}
+	rem := g.version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := g.version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		dark := bit(bits, i)
+		a, b := g.size-11+i%3, i/3
+		g.setFunction(a, b, dark)
+		g.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords place les octets de données et de correction en zigzag,
+// par colonnes de deux modules en partant du coin inférieur droit.
+func (g *grid) drawCodewords(data []byte) {
+	i := 0
+	for right := g.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // La colonne de synchronisation verticale est sautée.
+		}
+		for vert := 0; vert < g.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				upward := (right+1)&2 == 0
+				y := vert
+				if upward {
+					y = g.size - 1 - vert
+				}
+				if !g.isFunction[y][x] && i < len(data)*8 {
+					g.modules[y][x] = bit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverse les modules de données pour lesquels le motif de masque est vrai.
+func (g *grid) applyMask(mask int) {
+	for y := 0; y < g.size; y++ {
+		for x := 0; x < g.size; x++ {
+			if g.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				g.modules[y][x] = !g.modules[y][x]
+			}
+		}
+	}
+}
+
+// Pondérations des règles de pénalité de la norme.
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// penaltyScore évalue la lisibilité de la grille selon les quatre règles de la norme :
+// suites de modules identiques, blocs 2x2, motifs ressemblant aux motifs de position
+// et déséquilibre entre modules sombres et clairs.
+func (g *grid) penaltyScore() int {
+	score := 0
+	n := g.size
+
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return g.modules[x][y]
+		}
+		return g.modules[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x < n; x++ {
+				if at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += penaltyN1 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += penaltyN1 + run - 5
+			}
+
+			for x := 0; x+10 < n; x++ {
+				if matchesFinderLike(func(i int) bool { return at(x+i, y, vertical) }) {
+					score += penaltyN3
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if g.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := g.modules[y][x]
+				if c == g.modules[y][x+1] && c == g.modules[y+1][x] && c == g.modules[y+1][x+1] {
+					score += penaltyN2
+				}
+			}
+		}
+	}
+
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * penaltyN4
+	return score
+}
+
+// finderLike sont les deux motifs 1:1:3:1:1 précédés ou suivis de quatre modules clairs.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func matchesFinderLike(module func(i int) bool) bool {
+	for _, pattern := range finderLike {
+		match := true
+		for i, dark := range pattern {
+			if module(i) != dark {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func bit(x, i int) bool {
+	return (x>>uint(i))&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
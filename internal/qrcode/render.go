@@ -0,0 +1,66 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// quietZone est la marge claire obligatoire autour du code, en modules.
+const quietZone = 4
+
+// moduleScale retourne la taille d'un module en pixels pour une image d'environ size pixels.
+func (c *Code) moduleScale(size int) int {
+	scale := size / (c.Size + 2*quietZone)
+	if scale < 1 {
+		scale = 1
+	}
+	return scale
+}
+
+// WritePNG écrit le code au format PNG dans w. size est la largeur souhaitée en pixels :
+// l'image réelle est arrondie à un nombre entier de pixels par module.
+func (c *Code) WritePNG(w io.Writer, size int) error {
+	scale := c.moduleScale(size)
+	dim := (c.Size + 2*quietZone) * scale
+
+	img := image.NewPaletted(image.Rect(0, 0, dim, dim), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// SVG retourne le code au format SVG, d'une largeur d'environ size pixels.
+func (c *Code) SVG(size int) []byte {
+	scale := c.moduleScale(size)
+	dim := c.Size + 2*quietZone
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		dim*scale, dim*scale, dim, dim)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#FFFFFF"/>`+"\n")
+	buf.WriteString(`<path fill="#000000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&buf, "M%d,%dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+	return buf.Bytes()
+}
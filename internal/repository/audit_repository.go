@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditFilter restreint les événements retournés par ListEvents. Les champs vides sont ignorés.
+type AuditFilter struct {
+	Domain    *string // Domaine court des liens, nil pour tous les domaines ("" est le domaine principal)
+	ShortCode string
+	Actor     string
+	Action    string
+	Source    string
+	Since     *time.Time
+	Until     *time.Time
+	Limit     int
+}
+
+// AuditRepository donne accès au journal d'audit, en ajout seul.
+type AuditRepository interface {
+	CreateEvent(event *models.AuditEvent) error
+	ListEvents(filter AuditFilter) ([]models.AuditEvent, error)
+}
+
+// GormAuditRepository est l'implémentation de AuditRepository utilisant GORM.
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository crée et retourne une nouvelle instance de GormAuditRepository.
+func NewAuditRepository(db *gorm.DB) *GormAuditRepository {
+	return &GormAuditRepository{db: db}
+}
+
+// CreateEvent ajoute un événement au journal d'audit.
+func (r *GormAuditRepository) CreateEvent(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListEvents retourne les événements correspondant au filtre, les plus récents en premier.
+func (r *GormAuditRepository) ListEvents(filter AuditFilter) ([]models.AuditEvent, error) {
+	query := r.db.Order("id DESC").Limit(filter.Limit)
+	if filter.Domain != nil {
+		query = query.Where("domain = ?", *filter.Domain)
+	}
+	if filter.ShortCode != "" {
+		query = query.Where("short_code = ?", filter.ShortCode)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var events []models.AuditEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"sort"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/useragent"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // pour les opérations sur les clics. Cette abstraction permet à la couche service
@@ -12,6 +17,8 @@ type ClickRepository interface {
 	CreateClick(click *models.Click) error
 	CountClicksByLinkID(linkID uint) (int, error)
 	// Utilisé par LinkService pour les stats
+	FindClicksBefore(cutoff time.Time, limit int) ([]models.Click, error)
+	RollupClicks(rollups []models.ClickDailyRollup, clickIDs []uint) error
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -33,13 +40,183 @@ func (r *GormClickRepository) CreateClick(click *models.Click) error {
 
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
 // Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
+// Les clics déjà agrégés dans 'click_daily_rollups' sont inclus dans le total.
 func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les décomptes
+	return countClicksWithRollups(r.db, linkID)
+}
+
+// FindClicksBefore retourne au plus 'limit' clics bruts antérieurs à cutoff, du plus ancien au plus récent.
+func (r *GormClickRepository) FindClicksBefore(cutoff time.Time, limit int) ([]models.Click, error) {
+	var clicks []models.Click
+	if err := r.db.Where("timestamp < ?", cutoff).Order("id ASC").Limit(limit).Find(&clicks).Error; err != nil {
+		return nil, err
+	}
+	return clicks, nil
+}
+
+// RollupClicks ajoute les agrégats fournis aux agrégats existants puis supprime les clics bruts
+// correspondants, dans une seule transaction pour qu'aucun clic ne soit compté deux fois ou perdu.
+func (r *GormClickRepository) RollupClicks(rollups []models.ClickDailyRollup, clickIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(rollups) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "link_id"}, {Name: "day"}, {Name: "referrer"}, {Name: "device"}, {Name: "source"}, {Name: "version"}, {Name: "variant"}, {Name: "country"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"count": gorm.Expr("click_daily_rollups.count + excluded.count"),
+				}),
+			}).Create(&rollups).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(clickIDs) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", clickIDs).Delete(&models.Click{}).Error
+	})
+}
+
+// countClicksWithRollups additionne les clics bruts et les clics agrégés d'un lien.
+func countClicksWithRollups(db *gorm.DB, linkID uint) (int, error) {
+	var raw int64 // GORM retourne un int64 pour les décomptes
+	if err := db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&raw).Error; err != nil {
+		return 0, err
+	}
 
-	if err := r.db.Where("linkID = ?", linkID).Count(&count).Error; err != nil {
+	var rolled int64
+	if err := db.Model(&models.ClickDailyRollup{}).Where("link_id = ?", linkID).
+		Select("COALESCE(SUM(count), 0)").Scan(&rolled).Error; err != nil {
 		return 0, err
 	}
-	// où 'LinkID' correspond à l'ID de lien fourni.
 
-	return int(count), nil // Convert the int64 count to an int
+	return int(raw + rolled), nil
+}
+
+// countClicksByDay retourne le nombre de clics par jour (UTC) d'un lien,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksByDay(db *gorm.DB, linkID uint) ([]models.DailyClickCount, error) {
+	var rows []models.DailyClickCount
+	err := db.Raw(`SELECT day, SUM(count) AS count FROM (
+			SELECT date(timestamp) AS day, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY date(timestamp)
+			UNION ALL
+			SELECT day, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY day
+		) GROUP BY day ORDER BY day ASC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksBySource retourne le nombre de clics d'un lien par canal d'origine,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksBySource(db *gorm.DB, linkID uint) ([]models.SourceClickCount, error) {
+	var rows []models.SourceClickCount
+	err := db.Raw(`SELECT source, SUM(count) AS count FROM (
+			SELECT COALESCE(source, '') AS source, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY COALESCE(source, '')
+			UNION ALL
+			SELECT source, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY source
+		) GROUP BY source ORDER BY count DESC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksByVersion retourne le nombre de clics d'un lien par version de destination,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksByVersion(db *gorm.DB, linkID uint) ([]models.VersionClickCount, error) {
+	var rows []models.VersionClickCount
+	err := db.Raw(`SELECT version, SUM(count) AS count FROM (
+			SELECT version, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY version
+			UNION ALL
+			SELECT version, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY version
+		) GROUP BY version ORDER BY version ASC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksByVariant retourne le nombre de clics d'un lien par variante A/B,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksByVariant(db *gorm.DB, linkID uint) ([]models.VariantClickCount, error) {
+	var rows []models.VariantClickCount
+	err := db.Raw(`SELECT variant, SUM(count) AS count FROM (
+			SELECT COALESCE(variant, '') AS variant, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY COALESCE(variant, '')
+			UNION ALL
+			SELECT variant, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY variant
+		) GROUP BY variant ORDER BY count DESC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksByCountry retourne le nombre de clics d'un lien par pays,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksByCountry(db *gorm.DB, linkID uint) ([]models.CountryClickCount, error) {
+	var rows []models.CountryClickCount
+	err := db.Raw(`SELECT country, SUM(count) AS count FROM (
+			SELECT COALESCE(country, '') AS country, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY COALESCE(country, '')
+			UNION ALL
+			SELECT country, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY country
+		) GROUP BY country ORDER BY count DESC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksByReferrer retourne le nombre de clics d'un lien par domaine référent,
+// en fusionnant les clics bruts et les agrégats journaliers.
+func countClicksByReferrer(db *gorm.DB, linkID uint) ([]models.ReferrerClickCount, error) {
+	var rows []models.ReferrerClickCount
+	err := db.Raw(`SELECT referrer, SUM(count) AS count FROM (
+			SELECT COALESCE(referrer, '') AS referrer, COUNT(*) AS count FROM clicks WHERE link_id = ? GROUP BY COALESCE(referrer, '')
+			UNION ALL
+			SELECT referrer, SUM(count) AS count FROM click_daily_rollups WHERE link_id = ? GROUP BY referrer
+		) GROUP BY referrer ORDER BY count DESC`, linkID, linkID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countClicksByDevice retourne le nombre de clics d'un lien par type d'appareil. Les clics
+// bruts ne conservent que leur User-Agent : le type d'appareil en est déduit comme lors de
+// l'agrégation, puis fusionné avec les agrégats journaliers.
+func countClicksByDevice(db *gorm.DB, linkID uint) ([]models.DeviceClickCount, error) {
+	var raw []struct {
+		UserAgent string
+		Count     int
+	}
+	if err := db.Raw(`SELECT COALESCE(user_agent, '') AS user_agent, COUNT(*) AS count FROM clicks
+		WHERE link_id = ? GROUP BY COALESCE(user_agent, '')`, linkID).Scan(&raw).Error; err != nil {
+		return nil, err
+	}
+	var rolled []models.DeviceClickCount
+	if err := db.Raw(`SELECT device, SUM(count) AS count FROM click_daily_rollups
+		WHERE link_id = ? GROUP BY device`, linkID).Scan(&rolled).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, row := range raw {
+		counts[useragent.Parse(row.UserAgent).Device] += row.Count
+	}
+	for _, row := range rolled {
+		counts[row.Device] += row.Count
+	}
+
+	rows := make([]models.DeviceClickCount, 0, len(counts))
+	for device, count := range counts {
+		rows = append(rows, models.DeviceClickCount{Device: device, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Device < rows[j].Device
+	})
+	return rows, nil
 }
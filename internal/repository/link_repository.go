@@ -3,13 +3,28 @@ package repository
 import (
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
+	UpdateLink(link *models.Link, changes LinkChanges) error
+	DeleteLink(link *models.Link) error
+	SetLinkWorkspace(link *models.Link, workspaceID uint) error
 	GetAllLinks() ([]models.Link, error)
-	GetLinkByShortCode(shortcode string) (*models.Link, error)
+	GetLinkByShortCode(domain, shortcode string) (*models.Link, error)
+	GetLinkByID(id uint) (*models.Link, error)
+	ShortCodeExists(domain, shortcode string) (bool, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	CountClicksByDay(linkID uint) ([]models.DailyClickCount, error)
+	CountClicksBySource(linkID uint) ([]models.SourceClickCount, error)
+	CountClicksByVersion(linkID uint) ([]models.VersionClickCount, error)
+	CountClicksByVariant(linkID uint) ([]models.VariantClickCount, error)
+	CountClicksByCountry(linkID uint) ([]models.CountryClickCount, error)
+	CountClicksByReferrer(linkID uint) ([]models.ReferrerClickCount, error)
+	CountClicksByDevice(linkID uint) ([]models.DeviceClickCount, error)
+	GetLinkVersions(linkID uint) ([]models.LinkVersion, error)
+	GetLinkVersion(linkID uint, version int) (*models.LinkVersion, error)
 }
 
 // pour les opérations CRUD sur les liens.
@@ -31,17 +46,135 @@ func (r *GormLinkRepository) CreateLink(link *models.Link) error {
 	return r.db.Create(link).Error
 }
 
-// GetLinkByShortCode récupère un lien de la base de données en utilisant son shortCode.
-// Il renvoie gorm.ErrRecordNotFound si aucun lien n'est trouvé avec ce shortCode.
-func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+// LinkChanges décrit les données rattachées à un lien qui changent avec lui.
+type LinkChanges struct {
+	Version  *models.LinkVersion     // Nouvelle version de destination, nil si la destination ne change pas
+	Variants *[]models.LinkVariant   // Variantes A/B remplaçant les actuelles, nil pour les conserver
+	Rules    *[]models.TargetingRule // Règles de ciblage remplaçant les actuelles, nil pour les conserver
+}
+
+// UpdateLink enregistre les modifications apportées à un lien existant, ainsi que sa nouvelle
+// version de destination, ses nouvelles variantes et ses nouvelles règles, dans une même transaction.
+func (r *GormLinkRepository) UpdateLink(link *models.Link, changes LinkChanges) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(link).Error; err != nil {
+			return err
+		}
+		if changes.Version != nil {
+			if err := tx.Create(changes.Version).Error; err != nil {
+				return err
+			}
+		}
+		if changes.Variants != nil {
+			if err := tx.Where("link_id = ?", link.ID).Delete(&models.LinkVariant{}).Error; err != nil {
+				return err
+			}
+			variants := *changes.Variants
+			for i := range variants {
+				variants[i].LinkID = link.ID
+			}
+			if len(variants) > 0 {
+				if err := tx.Create(&variants).Error; err != nil {
+					return err
+				}
+			}
+			link.Variants = variants
+		}
+		if changes.Rules != nil {
+			if err := tx.Where("link_id = ?", link.ID).Delete(&models.TargetingRule{}).Error; err != nil {
+				return err
+			}
+			rules := *changes.Rules
+			for i := range rules {
+				rules[i].LinkID = link.ID
+			}
+			if len(rules) > 0 {
+				if err := tx.Create(&rules).Error; err != nil {
+					return err
+				}
+			}
+			link.Rules = rules
+		}
+		return nil
+	})
+}
+
+// GetLinkVersions retourne les versions de destination d'un lien, de la plus ancienne à la plus récente.
+func (r *GormLinkRepository) GetLinkVersions(linkID uint) ([]models.LinkVersion, error) {
+	var versions []models.LinkVersion
+	if err := r.db.Where("link_id = ?", linkID).Order("version ASC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetLinkVersion récupère une version de destination d'un lien.
+// Il renvoie gorm.ErrRecordNotFound si cette version n'existe pas.
+func (r *GormLinkRepository) GetLinkVersion(linkID uint, version int) (*models.LinkVersion, error) {
+	var v models.LinkVersion
+	if err := r.db.Where("link_id = ? AND version = ?", linkID, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DeleteLink supprime logiquement un lien : il n'est plus retourné par les requêtes
+// mais reste en base avec ses clics.
+func (r *GormLinkRepository) DeleteLink(link *models.Link) error {
+	return r.db.Delete(link).Error
+}
+
+// GetLinkByShortCode récupère un lien de la base de données en utilisant son domaine et son shortCode,
+// avec ses variantes A/B et ses règles de ciblage. Le domaine principal est la chaîne vide.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien n'est trouvé avec ce shortCode sur ce domaine.
+func (r *GormLinkRepository) GetLinkByShortCode(domain, shortCode string) (*models.Link, error) {
 	var link models.Link
-	if err := r.db.Where("shortcode = ?", shortCode).First(&link).Error; err != nil {
+	if err := withDestinations(r.db).Where("domain = ? AND shortcode = ?", domain, shortCode).First(&link).Error; err != nil {
 		return nil, err
 	}
 	return &link, nil
 	// La méthode First de GORM recherche le premier enregistrement correspondant et le mappe à 'link'.
 }
 
+// GetLinkByID récupère un lien par son identifiant, avec ses variantes A/B et ses règles de ciblage.
+// SetLinkWorkspace rattache un lien à un espace de travail, 0 pour le détacher de tout espace.
+// Seule cette colonne est modifiée.
+func (r *GormLinkRepository) SetLinkWorkspace(link *models.Link, workspaceID uint) error {
+	if err := r.db.Model(&models.Link{}).Where("id = ?", link.ID).Update("workspace_id", workspaceID).Error; err != nil {
+		return err
+	}
+	link.WorkspaceID = workspaceID
+	return nil
+}
+
+// Il renvoie gorm.ErrRecordNotFound si aucun lien n'est trouvé.
+func (r *GormLinkRepository) GetLinkByID(id uint) (*models.Link, error) {
+	var link models.Link
+	if err := withDestinations(r.db).First(&link, id).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// withDestinations charge les destinations alternatives d'un lien, nécessaires à sa redirection :
+// ses variantes A/B et ses règles de ciblage, dans leur ordre d'évaluation.
+func withDestinations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Rules", func(db *gorm.DB) *gorm.DB {
+		return db.Order("priority ASC, id ASC")
+	})
+}
+
+// ShortCodeExists indique si un lien du domaine, même supprimé, utilise déjà ce shortCode.
+func (r *GormLinkRepository) ShortCodeExists(domain, shortCode string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&models.Link{}).Where("domain = ? AND shortcode = ?", domain, shortCode).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetAllLinks récupère tous les liens de la base de données.
 // Cette méthode est utilisée par le moniteur d'URLs.
 func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
@@ -52,13 +185,43 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné,
+// clics bruts et clics agrégés par la rétention confondus.
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les comptes
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
-		return 0, err
-	}
-	// où 'LinkID' correspond à l'ID du lien donné.
+	return countClicksWithRollups(r.db, linkID)
+}
+
+// CountClicksByDay retourne le nombre de clics par jour pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByDay(linkID uint) ([]models.DailyClickCount, error) {
+	return countClicksByDay(r.db, linkID)
+}
+
+// CountClicksBySource retourne le nombre de clics par canal d'origine pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksBySource(linkID uint) ([]models.SourceClickCount, error) {
+	return countClicksBySource(r.db, linkID)
+}
+
+// CountClicksByVersion retourne le nombre de clics par version de destination pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByVersion(linkID uint) ([]models.VersionClickCount, error) {
+	return countClicksByVersion(r.db, linkID)
+}
+
+// CountClicksByVariant retourne le nombre de clics par variante A/B pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByVariant(linkID uint) ([]models.VariantClickCount, error) {
+	return countClicksByVariant(r.db, linkID)
+}
+
+// CountClicksByCountry retourne le nombre de clics par pays pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByCountry(linkID uint) ([]models.CountryClickCount, error) {
+	return countClicksByCountry(r.db, linkID)
+}
+
+// CountClicksByReferrer retourne le nombre de clics par domaine référent pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByReferrer(linkID uint) ([]models.ReferrerClickCount, error) {
+	return countClicksByReferrer(r.db, linkID)
+}
 
-	return int(count), nil
+// CountClicksByDevice retourne le nombre de clics par type d'appareil pour un ID de lien donné.
+func (r *GormLinkRepository) CountClicksByDevice(linkID uint) ([]models.DeviceClickCount, error) {
+	return countClicksByDevice(r.db, linkID)
 }
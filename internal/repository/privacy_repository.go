@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErasureCounts est le nombre de lignes touchées par une action de protection des données,
+// par table. En simulation, ce sont les lignes qui seraient touchées.
+type ErasureCounts struct {
+	Links            int64 `json:"links,omitempty"`
+	Clicks           int64 `json:"clicks,omitempty"`
+	ClickRollups     int64 `json:"click_rollups,omitempty"`
+	Versions         int64 `json:"versions,omitempty"`
+	Variants         int64 `json:"variants,omitempty"`
+	Rules            int64 `json:"rules,omitempty"`
+	ScheduledChanges int64 `json:"scheduled_changes,omitempty"`
+	Reports          int64 `json:"reports,omitempty"`
+	StatusChanges    int64 `json:"status_changes,omitempty"`
+}
+
+// errDryRun annule la transaction d'une simulation après le décompte des lignes touchées.
+var errDryRun = errors.New("dry run")
+
+// PrivacyRepository efface ou anonymise les données personnelles des visiteurs.
+// En simulation (dryRun), chaque opération s'exécute dans une transaction annulée :
+// les décomptes retournés sont exactement ceux de l'opération réelle.
+type PrivacyRepository interface {
+	EraseVisitor(ipValues []string, dryRun bool) (ErasureCounts, error)
+	PurgeLink(link *models.Link, dryRun bool) (ErasureCounts, error)
+	AnonymizeBefore(before time.Time, dryRun bool) (ErasureCounts, error)
+	GetLinkIncludingDeleted(domain, shortCode string) (*models.Link, error)
+}
+
+// GormPrivacyRepository est l'implémentation de PrivacyRepository utilisant GORM.
+type GormPrivacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository crée et retourne une nouvelle instance de GormPrivacyRepository.
+func NewPrivacyRepository(db *gorm.DB) *GormPrivacyRepository {
+	return &GormPrivacyRepository{db: db}
+}
+
+// run exécute fn dans une transaction, annulée en simulation.
+func (r *GormPrivacyRepository) run(dryRun bool, fn func(tx *gorm.DB, counts *ErasureCounts) error) (ErasureCounts, error) {
+	var counts ErasureCounts
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx, &counts); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return counts, err
+}
+
+// EraseVisitor supprime les clics dont l'adresse IP enregistrée fait partie de ipValues
+// (adresse complète ou empreinte) et retire cette adresse des signalements d'abus.
+func (r *GormPrivacyRepository) EraseVisitor(ipValues []string, dryRun bool) (ErasureCounts, error) {
+	return r.run(dryRun, func(tx *gorm.DB, counts *ErasureCounts) error {
+		res := tx.Where("ip_address IN ?", ipValues).Delete(&models.Click{})
+		if res.Error != nil {
+			return res.Error
+		}
+		counts.Clicks = res.RowsAffected
+
+		res = tx.Model(&models.AbuseReport{}).Where("reporter_ip IN ?", ipValues).Update("reporter_ip", "")
+		if res.Error != nil {
+			return res.Error
+		}
+		counts.Reports = res.RowsAffected
+		return nil
+	})
+}
+
+// PurgeLink supprime définitivement un lien, même supprimé logiquement, avec ses clics,
+// ses agrégats et toutes les données qui lui sont rattachées. Le journal d'audit, en ajout
+// seul, est conservé.
+func (r *GormPrivacyRepository) PurgeLink(link *models.Link, dryRun bool) (ErasureCounts, error) {
+	return r.run(dryRun, func(tx *gorm.DB, counts *ErasureCounts) error {
+		steps := []struct {
+			model interface{}
+			count *int64
+		}{
+			{&models.Click{}, &counts.Clicks},
+			{&models.ClickDailyRollup{}, &counts.ClickRollups},
+			{&models.LinkVersion{}, &counts.Versions},
+			{&models.LinkVariant{}, &counts.Variants},
+			{&models.TargetingRule{}, &counts.Rules},
+			{&models.ScheduledChange{}, &counts.ScheduledChanges},
+			{&models.AbuseReport{}, &counts.Reports},
+			{&models.LinkStatusChange{}, &counts.StatusChanges},
+		}
+		for _, step := range steps {
+			res := tx.Where("link_id = ?", link.ID).Delete(step.model)
+			if res.Error != nil {
+				return res.Error
+			}
+			*step.count = res.RowsAffected
+		}
+
+		res := tx.Unscoped().Delete(&models.Link{}, link.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		counts.Links = res.RowsAffected
+		return nil
+	})
+}
+
+// AnonymizeBefore efface l'adresse IP des clics et des signalements antérieurs à before.
+// Les clics sont conservés pour les statistiques.
+func (r *GormPrivacyRepository) AnonymizeBefore(before time.Time, dryRun bool) (ErasureCounts, error) {
+	return r.run(dryRun, func(tx *gorm.DB, counts *ErasureCounts) error {
+		res := tx.Model(&models.Click{}).Where("timestamp < ? AND ip_address <> ''", before).Update("ip_address", "")
+		if res.Error != nil {
+			return res.Error
+		}
+		counts.Clicks = res.RowsAffected
+
+		res = tx.Model(&models.AbuseReport{}).Where("created_at < ? AND reporter_ip <> ''", before).Update("reporter_ip", "")
+		if res.Error != nil {
+			return res.Error
+		}
+		counts.Reports = res.RowsAffected
+		return nil
+	})
+}
+
+// GetLinkIncludingDeleted récupère un lien par son domaine et son shortCode, y compris s'il a été
+// supprimé logiquement. Il renvoie gorm.ErrRecordNotFound si aucun lien n'utilise ce shortCode.
+func (r *GormPrivacyRepository) GetLinkIncludingDeleted(domain, shortCode string) (*models.Link, error) {
+	var link models.Link
+	if err := r.db.Unscoped().Where("domain = ? AND shortcode = ?", domain, shortCode).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReportRepository regroupe les opérations de modération : signalements d'abus
+// et historique des changements d'état des liens.
+type ReportRepository interface {
+	CreateReport(report *models.AbuseReport) error
+	GetReport(id uint) (*models.AbuseReport, error)
+	UpdateReport(report *models.AbuseReport) error
+	ListReports(status string, limit int) ([]models.AbuseReport, error)
+	SetLinkStatus(link *models.Link, change *models.LinkStatusChange) error
+	ListStatusChanges(linkID uint) ([]models.LinkStatusChange, error)
+}
+
+// GormReportRepository est l'implémentation de ReportRepository utilisant GORM.
+type GormReportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository crée et retourne une nouvelle instance de GormReportRepository.
+func NewReportRepository(db *gorm.DB) *GormReportRepository {
+	return &GormReportRepository{db: db}
+}
+
+// CreateReport enregistre un nouveau signalement d'abus.
+func (r *GormReportRepository) CreateReport(report *models.AbuseReport) error {
+	return r.db.Create(report).Error
+}
+
+// GetReport récupère un signalement et son lien.
+// Il renvoie gorm.ErrRecordNotFound si le signalement n'existe pas.
+func (r *GormReportRepository) GetReport(id uint) (*models.AbuseReport, error) {
+	var report models.AbuseReport
+	if err := r.db.Preload("Link", withDeleted).First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// UpdateReport enregistre les modifications apportées à un signalement.
+func (r *GormReportRepository) UpdateReport(report *models.AbuseReport) error {
+	return r.db.Omit("Link").Save(report).Error
+}
+
+// ListReports retourne les signalements, les plus anciens en premier, filtrés par état
+// si status n'est pas vide.
+func (r *GormReportRepository) ListReports(status string, limit int) ([]models.AbuseReport, error) {
+	query := r.db.Preload("Link", withDeleted).Order("id ASC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var reports []models.AbuseReport
+	if err := query.Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// SetLinkStatus change l'état d'un lien et enregistre ce changement dans son historique,
+// dans une même transaction.
+func (r *GormReportRepository) SetLinkStatus(link *models.Link, change *models.LinkStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(link).Update("status", link.Status).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// ListStatusChanges retourne l'historique des changements d'état d'un lien, du plus ancien au plus récent.
+func (r *GormReportRepository) ListStatusChanges(linkID uint) ([]models.LinkStatusChange, error) {
+	var changes []models.LinkStatusChange
+	if err := r.db.Where("link_id = ?", linkID).Order("id ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// withDeleted inclut les liens supprimés lors du chargement de la relation Link.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
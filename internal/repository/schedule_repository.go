@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// ScheduleRepository gère les changements de destination programmés des liens.
+// Chaque écriture tient à jour Link.NextChangeAt, l'échéance du prochain changement en attente.
+type ScheduleRepository interface {
+	CreateChange(change *models.ScheduledChange) error
+	GetChange(linkID, id uint) (*models.ScheduledChange, error)
+	ListChanges(linkID uint) ([]models.ScheduledChange, error)
+	FindDueChanges(now time.Time, limit int) ([]models.ScheduledChange, error)
+	CancelChange(change *models.ScheduledChange) (bool, error)
+	ApplyChange(change *models.ScheduledChange, createdBy string) (before, after *models.Link, err error)
+	FailChange(change *models.ScheduledChange) error
+}
+
+// GormScheduleRepository est l'implémentation de ScheduleRepository utilisant GORM.
+type GormScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduleRepository crée et retourne une nouvelle instance de GormScheduleRepository.
+func NewScheduleRepository(db *gorm.DB) *GormScheduleRepository {
+	return &GormScheduleRepository{db: db}
+}
+
+// CreateChange enregistre un changement programmé.
+func (r *GormScheduleRepository) CreateChange(change *models.ScheduledChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		return refreshNextChange(tx, change.LinkID)
+	})
+}
+
+// GetChange récupère un changement programmé d'un lien.
+// Il renvoie gorm.ErrRecordNotFound s'il n'existe pas.
+func (r *GormScheduleRepository) GetChange(linkID, id uint) (*models.ScheduledChange, error) {
+	var change models.ScheduledChange
+	if err := r.db.Where("link_id = ? AND id = ?", linkID, id).First(&change).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// ListChanges retourne les changements programmés d'un lien, par échéance croissante.
+func (r *GormScheduleRepository) ListChanges(linkID uint) ([]models.ScheduledChange, error) {
+	var changes []models.ScheduledChange
+	if err := r.db.Where("link_id = ?", linkID).Order("at ASC, id ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// FindDueChanges retourne au plus limit changements en attente arrivés à échéance, les plus anciens en premier.
+func (r *GormScheduleRepository) FindDueChanges(now time.Time, limit int) ([]models.ScheduledChange, error) {
+	var changes []models.ScheduledChange
+	err := r.db.Where("status = ? AND at <= ?", models.ScheduleStatusPending, now).
+		Order("at ASC, id ASC").Limit(limit).Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// CancelChange annule un changement encore en attente. Il retourne false si le changement
+// a déjà été appliqué, annulé ou refusé entre-temps.
+func (r *GormScheduleRepository) CancelChange(change *models.ScheduledChange) (bool, error) {
+	cancelled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimChange(tx, change.ID, models.ScheduleStatusCancelled, nil)
+		if err != nil || !claimed {
+			return err
+		}
+		cancelled = true
+		return refreshNextChange(tx, change.LinkID)
+	})
+	if cancelled {
+		change.Status = models.ScheduleStatusCancelled
+	}
+	return cancelled, err
+}
+
+// ApplyChange applique un changement en attente : la nouvelle destination du lien et sa version
+// sont enregistrées dans la même transaction que le passage du changement à l'état appliqué.
+// Le lien est relu dans la transaction, après la réservation du changement, et seules sa
+// destination et sa version sont modifiées : une modification concurrente du lien (autres
+// champs, état de modération) est préservée et deux changements ne peuvent pas créer la même
+// version. Il retourne le lien avant et après le changement, ou after nil si le changement a
+// déjà été traité, par exemple par une autre instance, et gorm.ErrRecordNotFound si le lien
+// a été supprimé.
+func (r *GormScheduleRepository) ApplyChange(change *models.ScheduledChange, createdBy string) (before, after *models.Link, err error) {
+	now := time.Now()
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimChange(tx, change.ID, models.ScheduleStatusApplied, &now)
+		if err != nil || !claimed {
+			return err
+		}
+
+		var link models.Link
+		if err := withDestinations(tx).First(&link, change.LinkID).Error; err != nil {
+			return err
+		}
+		previous := link
+		if change.LongURL != link.LongURL {
+			link.LongURL = change.LongURL
+			link.Version++
+			err := tx.Model(&models.Link{}).Where("id = ?", link.ID).
+				Updates(map[string]interface{}{"long_url": link.LongURL, "version": link.Version}).Error
+			if err != nil {
+				return err
+			}
+			version := &models.LinkVersion{LinkID: link.ID, Version: link.Version, LongURL: link.LongURL, CreatedBy: createdBy}
+			if err := tx.Create(version).Error; err != nil {
+				return err
+			}
+		}
+		if err := refreshNextChange(tx, link.ID); err != nil {
+			return err
+		}
+		if err := tx.Select("next_change_at").First(&link, link.ID).Error; err != nil {
+			return err
+		}
+		before, after = &previous, &link
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if after != nil {
+		change.Status = models.ScheduleStatusApplied
+		change.AppliedAt = &now
+	}
+	return before, after, nil
+}
+
+// FailChange marque comme refusé un changement dont la destination n'a pas pu être appliquée.
+func (r *GormScheduleRepository) FailChange(change *models.ScheduledChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.ScheduledChange{}).
+			Where("id = ? AND status = ?", change.ID, models.ScheduleStatusPending).
+			Updates(map[string]interface{}{"status": models.ScheduleStatusFailed, "error": change.Error}).Error
+		if err != nil {
+			return err
+		}
+		return refreshNextChange(tx, change.LinkID)
+	})
+}
+
+// claimChange fait passer un changement en attente à l'état status. La condition sur l'état
+// courant garantit qu'un changement n'est traité qu'une seule fois.
+func claimChange(tx *gorm.DB, id uint, status string, appliedAt *time.Time) (bool, error) {
+	result := tx.Model(&models.ScheduledChange{}).
+		Where("id = ? AND status = ?", id, models.ScheduleStatusPending).
+		Updates(map[string]interface{}{"status": status, "applied_at": appliedAt})
+	return result.RowsAffected == 1, result.Error
+}
+
+// refreshNextChange recalcule Link.NextChangeAt à partir des changements encore en attente.
+func refreshNextChange(tx *gorm.DB, linkID uint) error {
+	return tx.Exec(`UPDATE links SET next_change_at =
+		(SELECT MIN(at) FROM scheduled_changes WHERE link_id = ? AND status = ?) WHERE id = ?`,
+		linkID, models.ScheduleStatusPending, linkID).Error
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository gère les abonnements aux webhooks, la file de leurs envois et les
+// envois abandonnés (lettres mortes).
+type WebhookRepository interface {
+	CreateWebhook(webhook *models.Webhook) error
+	GetWebhook(id uint) (*models.Webhook, error)
+	ListWebhooks() ([]models.Webhook, error)
+	DeleteWebhook(webhook *models.Webhook) error
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	FindDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	DeleteDelivery(delivery *models.WebhookDelivery) error
+	RetryDelivery(delivery *models.WebhookDelivery) error
+	KillDelivery(delivery *models.WebhookDelivery, dead *models.WebhookDeadLetter) error
+	ListDeadLetters(webhookID uint, limit int) ([]models.WebhookDeadLetter, error)
+	GetDeadLetter(id uint) (*models.WebhookDeadLetter, error)
+	RequeueDeadLetter(dead *models.WebhookDeadLetter, delivery *models.WebhookDelivery) error
+}
+
+// GormWebhookRepository est l'implémentation de WebhookRepository utilisant GORM.
+type GormWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository crée et retourne une nouvelle instance de GormWebhookRepository.
+func NewWebhookRepository(db *gorm.DB) *GormWebhookRepository {
+	return &GormWebhookRepository{db: db}
+}
+
+// CreateWebhook enregistre un abonnement.
+func (r *GormWebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// GetWebhook récupère un abonnement par son identifiant.
+// Il renvoie gorm.ErrRecordNotFound s'il n'existe pas.
+func (r *GormWebhookRepository) GetWebhook(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks retourne tous les abonnements, par ordre de création.
+func (r *GormWebhookRepository) ListWebhooks() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook supprime un abonnement avec ses envois en attente et ses lettres mortes.
+func (r *GormWebhookRepository) DeleteWebhook(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDeadLetter{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+// CreateDeliveries ajoute des envois à la file.
+func (r *GormWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// FindDueDeliveries retourne au plus limit envois dont la prochaine tentative est échue,
+// les plus anciens en premier.
+func (r *GormWebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at ASC, id ASC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// DeleteDelivery retire de la file un envoi réussi.
+func (r *GormWebhookRepository) DeleteDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Delete(delivery).Error
+}
+
+// RetryDelivery enregistre l'échec d'une tentative et la date de la suivante.
+func (r *GormWebhookRepository) RetryDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).Updates(map[string]interface{}{
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"last_error":      delivery.LastError,
+	}).Error
+}
+
+// KillDelivery déplace un envoi abandonné dans les lettres mortes.
+func (r *GormWebhookRepository) KillDelivery(delivery *models.WebhookDelivery, dead *models.WebhookDeadLetter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dead).Error; err != nil {
+			return err
+		}
+		return tx.Delete(delivery).Error
+	})
+}
+
+// ListDeadLetters retourne au plus limit lettres mortes, les plus récentes en premier.
+// webhookID restreint la liste à un abonnement s'il est non nul.
+func (r *GormWebhookRepository) ListDeadLetters(webhookID uint, limit int) ([]models.WebhookDeadLetter, error) {
+	query := r.db.Order("failed_at DESC, id DESC").Limit(limit)
+	if webhookID != 0 {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	var dead []models.WebhookDeadLetter
+	if err := query.Find(&dead).Error; err != nil {
+		return nil, err
+	}
+	return dead, nil
+}
+
+// GetDeadLetter récupère une lettre morte par son identifiant.
+// Il renvoie gorm.ErrRecordNotFound si elle n'existe pas.
+func (r *GormWebhookRepository) GetDeadLetter(id uint) (*models.WebhookDeadLetter, error) {
+	var dead models.WebhookDeadLetter
+	if err := r.db.First(&dead, id).Error; err != nil {
+		return nil, err
+	}
+	return &dead, nil
+}
+
+// RequeueDeadLetter remplace une lettre morte par un nouvel envoi dans la file.
+func (r *GormWebhookRepository) RequeueDeadLetter(dead *models.WebhookDeadLetter, delivery *models.WebhookDelivery) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(dead)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound // Déjà renvoyée entre-temps
+		}
+		return tx.Create(delivery).Error
+	})
+}
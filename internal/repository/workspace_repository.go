@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkspaceRepository gère les espaces de travail et leurs membres.
+type WorkspaceRepository interface {
+	CreateWorkspace(workspace *models.Workspace) error
+	GetWorkspaceBySlug(slug string) (*models.Workspace, error)
+	ListWorkspaces() ([]models.Workspace, error)
+	GetMember(workspaceID uint, member string) (*models.WorkspaceMember, error)
+	ListMembers(workspaceID uint) ([]models.WorkspaceMember, error)
+	SaveMember(member *models.WorkspaceMember) error
+	DeleteMember(member *models.WorkspaceMember) error
+	CountOwners(workspaceID uint) (int64, error)
+}
+
+// GormWorkspaceRepository est l'implémentation de WorkspaceRepository utilisant GORM.
+type GormWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository crée et retourne une nouvelle instance de GormWorkspaceRepository.
+func NewWorkspaceRepository(db *gorm.DB) *GormWorkspaceRepository {
+	return &GormWorkspaceRepository{db: db}
+}
+
+// CreateWorkspace crée un espace de travail avec ses membres initiaux.
+func (r *GormWorkspaceRepository) CreateWorkspace(workspace *models.Workspace) error {
+	return r.db.Create(workspace).Error
+}
+
+// GetWorkspaceBySlug récupère un espace de travail par son identifiant.
+// Il renvoie gorm.ErrRecordNotFound si aucun espace n'utilise ce slug.
+func (r *GormWorkspaceRepository) GetWorkspaceBySlug(slug string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.Where("slug = ?", slug).First(&workspace).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// ListWorkspaces retourne tous les espaces de travail, par ordre de création.
+func (r *GormWorkspaceRepository) ListWorkspaces() ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	if err := r.db.Order("id ASC").Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetMember récupère l'appartenance d'un client à un espace de travail.
+// Il renvoie gorm.ErrRecordNotFound si le client n'en est pas membre.
+func (r *GormWorkspaceRepository) GetMember(workspaceID uint, member string) (*models.WorkspaceMember, error) {
+	var m models.WorkspaceMember
+	if err := r.db.Where("workspace_id = ? AND member = ?", workspaceID, member).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListMembers retourne les membres d'un espace de travail, par ordre d'ajout.
+func (r *GormWorkspaceRepository) ListMembers(workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	if err := r.db.Where("workspace_id = ?", workspaceID).Order("id ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SaveMember ajoute un membre à un espace de travail, ou change son rôle s'il en est déjà membre.
+func (r *GormWorkspaceRepository) SaveMember(member *models.WorkspaceMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "member"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// DeleteMember retire un membre d'un espace de travail.
+func (r *GormWorkspaceRepository) DeleteMember(member *models.WorkspaceMember) error {
+	return r.db.Delete(member).Error
+}
+
+// CountOwners compte les propriétaires d'un espace de travail.
+func (r *GormWorkspaceRepository) CountOwners(workspaceID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, models.WorkspaceRoleOwner).
+		Count(&count).Error
+	return count, err
+}
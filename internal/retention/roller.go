@@ -0,0 +1,150 @@
+package retention
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/useragent"
+)
+
+// Roller agrège périodiquement les clics bruts plus anciens que la durée de rétention
+// dans 'click_daily_rollups', puis supprime les lignes brutes correspondantes.
+type Roller struct {
+	clickRepo repository.ClickRepository
+	rawDays   int
+	interval  time.Duration
+	batchSize int
+}
+
+// NewRoller crée un Roller qui conserve les clics bruts pendant rawDays jours.
+// La durée de rétention et l'intervalle doivent être strictement positifs : avec 0 jour,
+// les clics du jour même seraient agrégés et supprimés.
+func NewRoller(clickRepo repository.ClickRepository, rawDays int, interval time.Duration, batchSize int) (*Roller, error) {
+	if rawDays <= 0 {
+		return nil, fmt.Errorf("invalid retention period of %d day(s)", rawDays)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid retention interval %v", interval)
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Roller{
+		clickRepo: clickRepo,
+		rawDays:   rawDays,
+		interval:  interval,
+		batchSize: batchSize,
+	}, nil
+}
+
+// Start lance la boucle d'agrégation. Elle est bloquante et doit être appelée dans une goroutine.
+func (r *Roller) Start() {
+	log.Printf("[RETENTION] Agrégation des clics de plus de %d jour(s) toutes les %v.", r.rawDays, r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	// Exécute une première agrégation immédiatement au démarrage
+	r.runAndLog()
+
+	for range ticker.C {
+		r.runAndLog()
+	}
+}
+
+func (r *Roller) runAndLog() {
+	count, err := r.RunOnce(time.Now())
+	if err != nil {
+		log.Printf("[RETENTION] ERREUR lors de l'agrégation des clics : %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("[RETENTION] %d clic(s) agrégé(s) et supprimé(s).", count)
+	}
+}
+
+// RunOnce agrège tous les clics antérieurs à now - rawDays, par lots de batchSize,
+// et retourne le nombre de clics bruts traités.
+func (r *Roller) RunOnce(now time.Time) (int, error) {
+	if r.rawDays <= 0 {
+		return 0, fmt.Errorf("invalid retention of %d day(s)", r.rawDays)
+	}
+	cutoff := now.AddDate(0, 0, -r.rawDays)
+
+	total := 0
+	for {
+		clicks, err := r.clickRepo.FindClicksBefore(cutoff, r.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to load clicks to roll up: %w", err)
+		}
+		if len(clicks) == 0 {
+			return total, nil
+		}
+
+		rollups, ids := Aggregate(clicks)
+		if err := r.clickRepo.RollupClicks(rollups, ids); err != nil {
+			return total, fmt.Errorf("failed to store click rollups: %w", err)
+		}
+		total += len(clicks)
+
+		if len(clicks) < r.batchSize {
+			return total, nil
+		}
+	}
+}
+
+// Aggregate regroupe des clics bruts par lien, jour UTC, domaine référent, classe d'appareil, source
+// version de destination, variante A/B et pays.
+// Elle retourne les agrégats et les IDs des clics qu'ils remplacent.
+func Aggregate(clicks []models.Click) ([]models.ClickDailyRollup, []uint) {
+	type key struct {
+		linkID   uint
+		day      string
+		referrer string
+		device   string
+		source   string
+		version  int
+		variant  string
+		country  string
+	}
+
+	counts := make(map[key]int)
+	var order []key
+	ids := make([]uint, 0, len(clicks))
+
+	for _, click := range clicks {
+		k := key{
+			linkID:   click.LinkID,
+			day:      click.Timestamp.UTC().Format("2006-01-02"),
+			referrer: click.Referrer,
+			device:   useragent.Parse(click.UserAgent).Device,
+			source:   click.Source,
+			version:  click.Version,
+			variant:  click.Variant,
+			country:  click.Country,
+		}
+		if _, ok := counts[k]; !ok {
+			order = append(order, k)
+		}
+		counts[k]++
+		ids = append(ids, click.ID)
+	}
+
+	rollups := make([]models.ClickDailyRollup, 0, len(order))
+	for _, k := range order {
+		rollups = append(rollups, models.ClickDailyRollup{
+			LinkID:   k.linkID,
+			Day:      k.day,
+			Referrer: k.referrer,
+			Device:   k.device,
+			Source:   k.source,
+			Version:  k.version,
+			Variant:  k.variant,
+			Country:  k.country,
+			Count:    counts[k],
+		})
+	}
+	return rollups, ids
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// Scheduler applique périodiquement les changements de destination programmés arrivés à échéance.
+// Les redirections appliquent elles-mêmes un changement échu que le Scheduler n'a pas encore traité :
+// l'intervalle n'influe donc que sur le délai avant qu'un lien peu visité soit mis à jour.
+type Scheduler struct {
+	scheduleService *services.ScheduleService
+	interval        time.Duration
+	batchSize       int
+}
+
+// NewScheduler crée un Scheduler qui vérifie les changements échus à chaque intervalle.
+// L'intervalle doit être strictement positif.
+func NewScheduler(scheduleService *services.ScheduleService, interval time.Duration, batchSize int) (*Scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid scheduler interval %v", interval)
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Scheduler{
+		scheduleService: scheduleService,
+		interval:        interval,
+		batchSize:       batchSize,
+	}, nil
+}
+
+// Start lance la boucle du planificateur. Elle est bloquante et doit être appelée dans une goroutine.
+func (s *Scheduler) Start() {
+	log.Printf("[SCHEDULER] Vérification des changements programmés toutes les %v.", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	// Applique immédiatement les changements échus pendant que le serveur était arrêté
+	s.runAndLog()
+
+	for range ticker.C {
+		s.runAndLog()
+	}
+}
+
+func (s *Scheduler) runAndLog() {
+	for {
+		count, err := s.scheduleService.ApplyDueChanges(time.Now(), s.batchSize)
+		if err != nil {
+			log.Printf("[SCHEDULER] ERREUR lors de l'application des changements programmés : %v", err)
+			return
+		}
+		if count > 0 {
+			log.Printf("[SCHEDULER] %d changement(s) programmé(s) appliqué(s).", count)
+		}
+		if count < s.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Actor identifie l'auteur d'une action sur un lien, son origine (API ou CLI) et ses droits.
+type Actor struct {
+	Name   string // Ex: "key:marketing", "ip:203.0.113.7", "cli:alice"
+	Source string // models.AuditSourceAPI ou models.AuditSourceCLI
+
+	WorkspaceID uint   // Espace de travail au nom duquel l'auteur agit, 0 hors de tout espace
+	Role        string // Rôle de l'auteur dans WorkspaceID
+	Admin       bool   // Accès à tous les liens, quel que soit leur espace (CLI, planificateur, clés d'administration)
+	SharedView  bool   // Consultation des liens hors de tout espace (workspaces.shared_link_access: view)
+}
+
+// CLIActor retourne l'acteur des commandes lancées en ligne de commande,
+// identifié par l'utilisateur du système.
+func CLIActor() Actor {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return Actor{Name: "cli:" + name, Source: models.AuditSourceCLI, Admin: true}
+}
+
+// linkSnapshot est l'état d'un lien enregistré dans le journal d'audit.
+// Le hash du mot de passe n'y figure pas : seule la présence d'une protection est tracée.
+type linkSnapshot struct {
+	LongURL           string     `json:"long_url"`
+	RedirectType      int        `json:"redirect_type"`
+	ActiveFrom        *time.Time `json:"active_from"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	WorkspaceID       uint       `json:"workspace_id"`
+	TrackEveryClick   bool       `json:"track_every_click"`
+	ForwardQuery      bool       `json:"forward_query"`
+	PasswordProtected bool       `json:"password_protected"`
+	SignedOnly        bool       `json:"signed_only"`
+	Status            string     `json:"status"`
+	Variants          []Variant  `json:"variants,omitempty"`
+	StickyVariants    bool       `json:"sticky_variants,omitempty"`
+	Rules             []Rule     `json:"rules,omitempty"`
+}
+
+// snapshotLink sérialise l'état d'un lien pour le journal d'audit.
+func snapshotLink(link *models.Link) string {
+	data, err := json.Marshal(linkSnapshot{
+		LongURL:           link.LongURL,
+		RedirectType:      link.RedirectType,
+		ActiveFrom:        link.ActiveFrom,
+		ExpiresAt:         link.ExpiresAt,
+		WorkspaceID:       link.WorkspaceID,
+		TrackEveryClick:   link.TrackEveryClick,
+		ForwardQuery:      link.ForwardQuery,
+		PasswordProtected: link.IsProtected(),
+		SignedOnly:        link.SignedOnly,
+		Status:            link.Status,
+		Variants:          variantsOf(link),
+		StickyVariants:    link.StickyVariants,
+		Rules:             rulesOf(link),
+	})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// recordAudit ajoute un événement au journal d'audit. La modification du lien étant déjà
+// enregistrée, un échec d'écriture du journal est signalé dans les logs sans annuler l'action.
+func recordAudit(repo repository.AuditRepository, action string, actor Actor, link *models.Link, before, after string) {
+	if repo == nil {
+		return
+	}
+	event := &models.AuditEvent{
+		Action:    action,
+		LinkID:    link.ID,
+		Domain:    link.Domain,
+		ShortCode: link.Shortcode,
+		Actor:     actor.Name,
+		Source:    actor.Source,
+		Before:    before,
+		After:     after,
+	}
+	if err := repo.CreateEvent(event); err != nil {
+		log.Printf("[AUDIT] ERREUR lors de l'enregistrement de l'action %s sur %s par %s : %v",
+			action, link.Shortcode, actor.Name, err)
+	}
+}
+
+// AuditService donne accès en lecture au journal d'audit.
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+// NewAuditService crée et retourne une nouvelle instance de AuditService.
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// ListEvents retourne les événements du journal d'audit correspondant au filtre.
+func (s *AuditService) ListEvents(filter repository.AuditFilter) ([]models.AuditEvent, error) {
+	return s.auditRepo.ListEvents(filter)
+}
@@ -7,7 +7,8 @@ import (
 	"log"
 	"time"
 
-	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
@@ -16,8 +17,68 @@ import (
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Erreurs de validation retournées par LinkService, à traduire en 400 par l'API.
+var (
+	ErrInvalidRedirectType  = errors.New("redirect type must be one of 301, 302, 307 or 308")
+	ErrExpiryInPast         = errors.New("expiry date must be in the future")
+	ErrInvalidActiveWindow  = errors.New("active_from must be before the expiry date")
+	ErrConflictingClear     = errors.New("a date cannot be set and cleared at the same time")
+	ErrPasswordTooShort     = errors.New("password must be at least 8 characters long")
+	ErrPasswordTooLong      = errors.New("password must be at most 72 bytes long")
+	ErrVersionNotFound      = errors.New("link version not found")
+	ErrVersionAlreadyActive = errors.New("link version is already active")
+)
+
+// minPasswordLength est la longueur minimale du mot de passe d'un lien protégé.
+const minPasswordLength = 8
+
+// LinkOptions regroupe les paramètres optionnels d'un lien à sa création.
+type LinkOptions struct {
+	Domain          string // Domaine court du lien, vide pour le domaine principal
+	RedirectType    int    // 0 pour utiliser la valeur par défaut du serveur
+	ExpiresAt       *time.Time
+	ActiveFrom      *time.Time // Date de mise en ligne, nil pour un lien actif immédiatement
+	TrackEveryClick bool
+	ForwardQuery    bool
+	UTM             UTMParams // Paramètres de campagne ajoutés à l'URL longue
+	Password        string    // Mot de passe protégeant le lien, vide pour un lien public
+	SignedOnly      bool      // N'accepte que les URLs signées
+	Variants        []Variant // Destinations pondérées d'un test A/B, vide pour une destination unique
+	StickyVariants  bool      // Sert toujours la même variante à un visiteur
+	Rules           []Rule    // Règles de ciblage évaluées avant la destination par défaut
+}
+
+// LinkUpdate décrit une modification partielle d'un lien : seuls les champs non nil sont appliqués.
+type LinkUpdate struct {
+	LongURL         *string
+	RedirectType    *int
+	ExpiresAt       *time.Time
+	ActiveFrom      *time.Time
+	ClearExpiresAt  bool // Retire la date d'expiration : le lien redevient permanent
+	ClearActiveFrom bool // Retire la date de mise en ligne : le lien est actif immédiatement
+	TrackEveryClick *bool
+	ForwardQuery    *bool
+	Password        *string // Chaîne vide pour retirer la protection
+	SignedOnly      *bool
+	Variants        *[]Variant // Remplace les variantes A/B, liste vide pour les retirer
+	StickyVariants  *bool
+	Rules           *[]Rule // Remplace les règles de ciblage, liste vide pour les retirer
+}
+
+// IsValidRedirectType indique si code est un code de redirection accepté pour un lien.
+func IsValidRedirectType(code int) bool {
+	switch code {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
+}
+
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo  repository.LinkRepository
+	auditRepo repository.AuditRepository
+	urlPolicy *URLPolicy
+	webhooks  *WebhookService
 }
 
 // LinkService est une structure qui g fournit des méthodes pour la logique métier des liens.
@@ -25,9 +86,15 @@ type LinkService struct {
 // IMPORTANT : Le champ doit être du type de l'interface (non-pointeur).
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
+// auditRepo reçoit le journal des modifications et urlPolicy contrôle les URLs de destination ;
+// nil désactive l'un ou l'autre, ce qui est réservé aux usages en lecture seule qui ne créent
+// ni ne modifient de lien. webhooks reçoit les événements link.*, nil pour ne pas en publier.
+func NewLinkService(linkRepo repository.LinkRepository, auditRepo repository.AuditRepository, urlPolicy *URLPolicy, webhooks *WebhookService) *LinkService {
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:  linkRepo,
+		auditRepo: auditRepo,
+		urlPolicy: urlPolicy,
+		webhooks:  webhooks,
 	}
 }
 
@@ -51,7 +118,44 @@ func (s *LinkService) GenerateShortCode(length int) (string, error) {
 
 // CreateLink crée un nouveau lien raccourci.
 // Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+func (s *LinkService) CreateLink(actor Actor, longURL string, opts LinkOptions) (*models.Link, error) {
+	if err := authorizeCreate(actor); err != nil {
+		return nil, err
+	}
+	if opts.RedirectType != 0 && !IsValidRedirectType(opts.RedirectType) {
+		return nil, ErrInvalidRedirectType
+	}
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiryInPast
+	}
+	if !isValidWindow(opts.ActiveFrom, opts.ExpiresAt) {
+		return nil, ErrInvalidActiveWindow
+	}
+
+	// Ajoute les paramètres UTM fournis à l'URL de destination.
+	longURL, err := ApplyUTM(longURL, opts.UTM)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
+	}
+
+	variants, err := s.buildVariants(opts.Variants)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.buildRules(opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := hashPassword(opts.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	const maxRetries = 5
 	var shortCode string
 
@@ -67,20 +171,16 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 			return nil, fmt.Errorf("failed to generate short code: %w", err)
 		}
 
-		// Vérifie si le code généré existe déjà en base de données (GetLinkbyShortCode)
-		_, err = s.linkRepo.GetLinkByShortCode(code)
-		// On ignore la première valeur
-
+		// Vérifie si le code généré existe déjà en base de données, y compris parmi les liens
+		// supprimés : un code court n'est jamais réattribué.
+		taken, err := s.linkRepo.ShortCodeExists(opts.Domain, code)
 		if err != nil {
-			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-
-				shortCode = code // Le code est unique, on peut l'utiliser
-				break            // Sort de la boucle de retry
-			}
-			// Si c'est une autre erreur de base de données, retourne l'erreur.
 			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
 		}
+		if !taken {
+			shortCode = code // Le code est unique, on peut l'utiliser
+			break            // Sort de la boucle de retry
+		}
 
 		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
 		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
@@ -96,9 +196,24 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 	// Crée une nouvelle instance du modèle Link.
 
 	link := &models.Link{
-		LongURL:   longURL,
-		Shortcode: shortCode,
-		CreatedAt: time.Now(),
+		LongURL:         longURL,
+		Domain:          opts.Domain,
+		Shortcode:       shortCode,
+		RedirectType:    opts.RedirectType,
+		ExpiresAt:       opts.ExpiresAt,
+		ActiveFrom:      opts.ActiveFrom,
+		TrackEveryClick: opts.TrackEveryClick,
+		ForwardQuery:    opts.ForwardQuery,
+		PasswordHash:    passwordHash,
+		SignedOnly:      opts.SignedOnly,
+		Status:          models.LinkStatusActive,
+		WorkspaceID:     actor.WorkspaceID,
+		Version:         1,
+		Versions:        []models.LinkVersion{{Version: 1, LongURL: longURL, CreatedBy: actor.Name}},
+		Variants:        variants,
+		StickyVariants:  opts.StickyVariants,
+		Rules:           rules,
+		CreatedAt:       time.Now(),
 	}
 
 	// Persiste le nouveau lien dans la base de données via le repository (CreateLink)
@@ -106,31 +221,306 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 	if err := s.linkRepo.CreateLink(link); err != nil {
 		return nil, fmt.Errorf("failed to save link: %w", err)
 	}
+	recordAudit(s.auditRepo, models.AuditActionCreate, actor, link, "", snapshotLink(link))
+	s.webhooks.publishLink(models.AuditActionCreate, actor, link)
 
 	// Retourne le lien créé
 
 	return link, nil
 }
 
-// GetLinkByShortCode récupère un lien via son code court.
-// Il délègue l'opération de recherche au repository.
-func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+// UpdateLink applique une modification partielle au lien identifié par son domaine et son shortCode.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) UpdateLink(actor Actor, domain, shortCode string, update LinkUpdate) (*models.Link, error) {
+	if update.RedirectType != nil && *update.RedirectType != 0 && !IsValidRedirectType(*update.RedirectType) {
+		return nil, ErrInvalidRedirectType
+	}
+	if (update.ClearExpiresAt && update.ExpiresAt != nil) || (update.ClearActiveFrom && update.ActiveFrom != nil) {
+		return nil, ErrConflictingClear
+	}
+	if update.ExpiresAt != nil && !update.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiryInPast
+	}
+	if update.LongURL != nil {
+		if err := s.checkURL(*update.LongURL); err != nil {
+			return nil, err
+		}
+	}
+	var changes repository.LinkChanges
+	if update.Variants != nil {
+		variants, err := s.buildVariants(*update.Variants)
+		if err != nil {
+			return nil, err
+		}
+		changes.Variants = &variants
+	}
+	if update.Rules != nil {
+		rules, err := s.buildRules(*update.Rules)
+		if err != nil {
+			return nil, err
+		}
+		changes.Rules = &rules
+	}
+
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+	before := snapshotLink(link)
+
+	// Un changement de destination crée une nouvelle version du lien.
+	if update.LongURL != nil && *update.LongURL != link.LongURL {
+		link.LongURL = *update.LongURL
+		link.Version++
+		changes.Version = &models.LinkVersion{LinkID: link.ID, Version: link.Version, LongURL: link.LongURL, CreatedBy: actor.Name}
+	}
+	if update.RedirectType != nil {
+		link.RedirectType = *update.RedirectType
+	}
+	if update.ExpiresAt != nil {
+		link.ExpiresAt = update.ExpiresAt
+	}
+	if update.ActiveFrom != nil {
+		link.ActiveFrom = update.ActiveFrom
+	}
+	if update.ClearExpiresAt {
+		link.ExpiresAt = nil
+	}
+	if update.ClearActiveFrom {
+		link.ActiveFrom = nil
+	}
+	if !isValidWindow(link.ActiveFrom, link.ExpiresAt) {
+		return nil, ErrInvalidActiveWindow
+	}
+	if update.TrackEveryClick != nil {
+		link.TrackEveryClick = *update.TrackEveryClick
+	}
+	if update.ForwardQuery != nil {
+		link.ForwardQuery = *update.ForwardQuery
+	}
+	if update.Password != nil {
+		passwordHash, err := hashPassword(*update.Password)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = passwordHash
+	}
+	if update.SignedOnly != nil {
+		link.SignedOnly = *update.SignedOnly
+	}
+	if update.StickyVariants != nil {
+		link.StickyVariants = *update.StickyVariants
+	}
+
+	if err := s.linkRepo.UpdateLink(link, changes); err != nil {
+		return nil, fmt.Errorf("failed to update link: %w", err)
+	}
+	recordAudit(s.auditRepo, models.AuditActionUpdate, actor, link, before, snapshotLink(link))
+	s.webhooks.publishLink(models.AuditActionUpdate, actor, link)
+	return link, nil
+}
+
+// RollbackLink rétablit la destination d'une version précédente du lien. Le retour en arrière
+// crée une nouvelle version, copie de l'ancienne, afin de ne jamais réécrire l'historique.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) RollbackLink(actor Actor, domain, shortCode string, toVersion int) (*models.Link, error) {
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+	if toVersion == link.Version {
+		return nil, ErrVersionAlreadyActive
+	}
+
+	target, err := s.linkRepo.GetLinkVersion(link.ID, toVersion)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	// L'ancienne destination a pu être bloquée par la politique depuis.
+	if err := s.checkURL(target.LongURL); err != nil {
+		return nil, err
+	}
+
+	before := snapshotLink(link)
+	link.LongURL = target.LongURL
+	link.Version++
+	version := &models.LinkVersion{
+		LinkID:       link.ID,
+		Version:      link.Version,
+		LongURL:      target.LongURL,
+		RestoredFrom: target.Version,
+		CreatedBy:    actor.Name,
+	}
+	if err := s.linkRepo.UpdateLink(link, repository.LinkChanges{Version: version}); err != nil {
+		return nil, fmt.Errorf("failed to roll back link: %w", err)
+	}
+	recordAudit(s.auditRepo, models.AuditActionRollback, actor, link, before, snapshotLink(link))
+	s.webhooks.publishLink(models.AuditActionRollback, actor, link)
+	return link, nil
+}
+
+// GetLinkVersions retourne le lien et l'historique des versions de sa destination.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) GetLinkVersions(actor Actor, domain, shortCode string) (*models.Link, []models.LinkVersion, error) {
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionView)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := s.linkRepo.GetLinkVersions(link.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, versions, nil
+}
+
+// DeleteLink supprime le lien identifié par son domaine et son shortCode. La suppression est logique : le code
+// court n'est jamais réattribué et les clics du lien sont conservés.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) DeleteLink(actor Actor, domain, shortCode string) error {
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+	if err != nil {
+		return err
+	}
+	if err := s.linkRepo.DeleteLink(link); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	recordAudit(s.auditRepo, models.AuditActionDelete, actor, link, snapshotLink(link), "")
+	s.webhooks.publishLink(models.AuditActionDelete, actor, link)
+	return nil
+}
+
+// AssignWorkspace rattache un lien existant à l'espace de travail workspaceID, ou le détache
+// de tout espace avec 0 : seuls les membres de l'espace y ont alors accès. Réservé aux
+// administrateurs, le déplacement étant enregistré dans le journal d'audit.
+func (s *LinkService) AssignWorkspace(actor Actor, domain, shortCode string, workspaceID uint) (*models.Link, error) {
+	if !actor.Admin {
+		return nil, ErrForbidden
+	}
+	link, err := s.linkRepo.GetLinkByShortCode(domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	before := snapshotLink(link)
+	if err := s.linkRepo.SetLinkWorkspace(link, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to assign link to workspace: %w", err)
+	}
+	recordAudit(s.auditRepo, models.AuditActionUpdate, actor, link, before, snapshotLink(link))
+	s.webhooks.publishLink(models.AuditActionUpdate, actor, link)
+	return link, nil
+}
+
+// isValidWindow indique si la fenêtre d'activation [activeFrom, expiresAt[ n'est pas vide.
+func isValidWindow(activeFrom, expiresAt *time.Time) bool {
+	return activeFrom == nil || expiresAt == nil || activeFrom.Before(*expiresAt)
+}
+
+// checkURL applique la politique de sécurité des URLs à une destination.
+func (s *LinkService) checkURL(longURL string) error {
+	return checkURL(s.urlPolicy, longURL)
+}
+
+// CheckPassword vérifie le mot de passe saisi pour un lien protégé.
+// La comparaison bcrypt est volontairement lente pour freiner les attaques par force brute.
+func (s *LinkService) CheckPassword(link *models.Link, password string) bool {
+	if !link.IsProtected() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) == nil
+}
+
+// hashPassword calcule le hash bcrypt d'un mot de passe. Un mot de passe vide retourne
+// un hash vide, ce qui correspond à un lien public.
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) < minPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	if len(password) > 72 {
+		return "", ErrPasswordTooLong // bcrypt ignore silencieusement les octets au-delà de 72
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// GetLinkByShortCode récupère un lien via son domaine et son code court.
+// Il délègue l'opération de recherche au repository, sans contrôle d'accès : il sert à la
+// redirection publique, les routes de gestion passent par GetLink.
+func (s *LinkService) GetLinkByShortCode(domain, shortCode string) (*models.Link, error) {
 	// Retourner le lien trouvé ou une erreur si non trouvé/problème DB.
 
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	link, err := s.linkRepo.GetLinkByShortCode(domain, shortCode)
 
 	return link, err
 }
 
+// GetLink récupère un lien via son domaine et son code court, s'il est visible par actor.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas ou appartient à un autre espace de travail.
+func (s *LinkService) GetLink(actor Actor, domain, shortCode string) (*models.Link, error) {
+	return findLink(s.linkRepo, actor, domain, shortCode, PermissionView)
+}
+
+// GetEditableLink récupère un lien que actor peut modifier, par exemple pour émettre ses URLs
+// signées : un simple lecteur de l'espace de travail reçoit ErrForbidden.
+func (s *LinkService) GetEditableLink(actor Actor, domain, shortCode string) (*models.Link, error) {
+	return findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+}
+
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
 // Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-	// Récupérer le lien par son shortCode
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+func (s *LinkService) GetLinkStats(actor Actor, domain, shortCode string) (*models.Link, int, error) {
+	// Récupérer le lien par son shortCode, s'il est visible par l'auteur de la demande
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionView)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	// Compter le nombre de clics pour ce LinkID
+	// Compter le nombre de clics pour ce LinkID (clics bruts et agrégats de rétention)
 	nbr, err := s.linkRepo.CountClicksByLinkID(link.ID)
 
 	// on retourne les 3 valeurs
 	return link, nbr, err
 }
+
+// GetDailyClicks retourne le nombre de clics par jour d'un lien.
+// Les jours dont les clics bruts ont été agrégés par la rétention sont inclus de façon transparente.
+func (s *LinkService) GetDailyClicks(link *models.Link) ([]models.DailyClickCount, error) {
+	return s.linkRepo.CountClicksByDay(link.ID)
+}
+
+// GetClicksByVersion retourne le nombre de clics d'un lien par version de sa destination.
+func (s *LinkService) GetClicksByVersion(link *models.Link) ([]models.VersionClickCount, error) {
+	return s.linkRepo.CountClicksByVersion(link.ID)
+}
+
+// GetClicksByVariant retourne le nombre de clics d'un lien par variante A/B.
+func (s *LinkService) GetClicksByVariant(link *models.Link) ([]models.VariantClickCount, error) {
+	return s.linkRepo.CountClicksByVariant(link.ID)
+}
+
+// GetClicksByCountry retourne le nombre de clics d'un lien par pays du visiteur.
+func (s *LinkService) GetClicksByCountry(link *models.Link) ([]models.CountryClickCount, error) {
+	return s.linkRepo.CountClicksByCountry(link.ID)
+}
+
+// GetClicksByReferrer retourne le nombre de clics d'un lien par domaine référent.
+func (s *LinkService) GetClicksByReferrer(link *models.Link) ([]models.ReferrerClickCount, error) {
+	return s.linkRepo.CountClicksByReferrer(link.ID)
+}
+
+// GetClicksByDevice retourne le nombre de clics d'un lien par type d'appareil du visiteur.
+func (s *LinkService) GetClicksByDevice(link *models.Link) ([]models.DeviceClickCount, error) {
+	return s.linkRepo.CountClicksByDevice(link.ID)
+}
+
+// GetClicksBySource retourne le nombre de clics d'un lien par canal d'origine (ex: scans de QR code).
+func (s *LinkService) GetClicksBySource(link *models.Link) ([]models.SourceClickCount, error) {
+	return s.linkRepo.CountClicksBySource(link.ID)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Actions possibles lors du traitement d'un signalement.
+const (
+	ReportActionDismiss = "dismiss"
+	ReportActionDisable = "disable"
+	ReportActionBlock   = "block"
+)
+
+// maxReportDetails est la longueur maximale de la description d'un signalement.
+const maxReportDetails = 1000
+
+var (
+	// ErrInvalidReportReason est retournée pour un motif de signalement inconnu.
+	ErrInvalidReportReason = errors.New("invalid report reason: must be spam, phishing, malware, illegal or other")
+	// ErrReportDetailsTooLong est retournée lorsque la description d'un signalement est trop longue.
+	ErrReportDetailsTooLong = fmt.Errorf("report details must be at most %d characters", maxReportDetails)
+	// ErrInvalidLinkStatus est retournée pour un état de lien inconnu.
+	ErrInvalidLinkStatus = errors.New("invalid link status: must be active, disabled or blocked")
+	// ErrInvalidReportAction est retournée pour une action de modération inconnue.
+	ErrInvalidReportAction = errors.New("invalid action: must be dismiss, disable or block")
+	// ErrReportAlreadyResolved est retournée lorsqu'un signalement a déjà été traité.
+	ErrReportAlreadyResolved = errors.New("report has already been handled")
+)
+
+// ReportReasons sont les motifs acceptés pour un signalement d'abus.
+var ReportReasons = map[string]bool{
+	"spam":     true,
+	"phishing": true,
+	"malware":  true,
+	"illegal":  true,
+	"other":    true,
+}
+
+// ModerationService gère les signalements d'abus et l'état (actif, désactivé, bloqué) des liens.
+type ModerationService struct {
+	linkRepo   repository.LinkRepository
+	reportRepo repository.ReportRepository
+	auditRepo  repository.AuditRepository
+	webhooks   *WebhookService
+}
+
+// NewModerationService crée et retourne une nouvelle instance de ModerationService.
+// webhooks reçoit l'événement link.updated des changements d'état, nil pour ne pas en publier.
+func NewModerationService(linkRepo repository.LinkRepository, reportRepo repository.ReportRepository, auditRepo repository.AuditRepository, webhooks *WebhookService) *ModerationService {
+	return &ModerationService{
+		linkRepo:   linkRepo,
+		reportRepo: reportRepo,
+		auditRepo:  auditRepo,
+		webhooks:   webhooks,
+	}
+}
+
+// ReportLink enregistre un signalement d'abus sur un lien.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *ModerationService) ReportLink(domain, shortCode, reason, details, reporterIP string) (*models.AbuseReport, error) {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if !ReportReasons[reason] {
+		return nil, ErrInvalidReportReason
+	}
+	details = strings.TrimSpace(details)
+	if len(details) > maxReportDetails {
+		return nil, ErrReportDetailsTooLong
+	}
+
+	link, err := s.linkRepo.GetLinkByShortCode(domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AbuseReport{
+		LinkID:     link.ID,
+		Reason:     reason,
+		Details:    details,
+		ReporterIP: reporterIP,
+		Status:     models.ReportStatusOpen,
+	}
+	if err := s.reportRepo.CreateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+	report.Link = *link
+	return report, nil
+}
+
+// ListReports retourne la file des signalements, filtrée par état si status n'est pas vide.
+func (s *ModerationService) ListReports(status string, limit int) ([]models.AbuseReport, error) {
+	return s.reportRepo.ListReports(status, limit)
+}
+
+// ResolveReport traite un signalement ouvert. Les actions disable et block changent
+// l'état du lien concerné ; dismiss classe le signalement sans suite.
+func (s *ModerationService) ResolveReport(id uint, action string, actor Actor, note string) (*models.AbuseReport, error) {
+	var newStatus string
+	switch action {
+	case ReportActionDismiss:
+	case ReportActionDisable:
+		newStatus = models.LinkStatusDisabled
+	case ReportActionBlock:
+		newStatus = models.LinkStatusBlocked
+	default:
+		return nil, ErrInvalidReportAction
+	}
+
+	report, err := s.reportRepo.GetReport(id)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ReportStatusOpen {
+		return nil, ErrReportAlreadyResolved
+	}
+
+	if newStatus != "" {
+		reason := fmt.Sprintf("report #%d (%s)", report.ID, report.Reason)
+		if note != "" {
+			reason += ": " + note
+		}
+		if err := s.changeStatus(&report.Link, newStatus, actor, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	report.Status = models.ReportStatusResolved
+	if action == ReportActionDismiss {
+		report.Status = models.ReportStatusDismissed
+	}
+	report.Resolution = action
+	report.ResolvedBy = actor.Name
+	report.ResolvedAt = &now
+	if err := s.reportRepo.UpdateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to update report: %w", err)
+	}
+	return report, nil
+}
+
+// SetLinkStatus change l'état d'un lien et trace l'auteur et la raison du changement.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *ModerationService) SetLinkStatus(domain, shortCode, status string, actor Actor, reason string) (*models.Link, error) {
+	if status != models.LinkStatusActive && status != models.LinkStatusDisabled && status != models.LinkStatusBlocked {
+		return nil, ErrInvalidLinkStatus
+	}
+
+	link, err := s.linkRepo.GetLinkByShortCode(domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.changeStatus(link, status, actor, reason); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// StatusHistory retourne l'historique des changements d'état d'un lien.
+func (s *ModerationService) StatusHistory(domain, shortCode string) (*models.Link, []models.LinkStatusChange, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(domain, shortCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := s.reportRepo.ListStatusChanges(link.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, changes, nil
+}
+
+// changeStatus applique un nouvel état au lien. Un état inchangé n'est pas tracé.
+func (s *ModerationService) changeStatus(link *models.Link, status string, actor Actor, reason string) error {
+	previous := link.Status
+	if previous == "" {
+		previous = models.LinkStatusActive
+	}
+	if previous == status {
+		return nil
+	}
+
+	before := snapshotLink(link)
+	link.Status = status
+	change := &models.LinkStatusChange{
+		LinkID:     link.ID,
+		FromStatus: previous,
+		ToStatus:   status,
+		Actor:      actor.Name,
+		Reason:     reason,
+	}
+	if err := s.reportRepo.SetLinkStatus(link, change); err != nil {
+		return fmt.Errorf("failed to update link status: %w", err)
+	}
+	recordAudit(s.auditRepo, statusAuditAction[status], actor, link, before, snapshotLink(link))
+	s.webhooks.publishLink(statusAuditAction[status], actor, link)
+	return nil
+}
+
+// statusAuditAction associe chaque état de lien à l'action tracée dans le journal d'audit.
+var statusAuditAction = map[string]string{
+	models.LinkStatusActive:   models.AuditActionEnable,
+	models.LinkStatusDisabled: models.AuditActionDisable,
+	models.LinkStatusBlocked:  models.AuditActionBlock,
+}
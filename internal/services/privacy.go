@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Erreurs de validation retournées par PrivacyService.
+var (
+	ErrNoVisitorCriteria = errors.New("an ip or a visitor_id is required")
+	ErrInvalidIP         = errors.New("invalid ip address")
+	ErrInvalidAge        = errors.New("age must be at least 1 day")
+)
+
+// PrivacyResult décrit le résultat d'une action de protection des données.
+type PrivacyResult struct {
+	DryRun bool                     `json:"dry_run"`
+	Counts repository.ErasureCounts `json:"affected"`
+}
+
+// PrivacyService regroupe les outils de protection des données personnelles (RGPD) :
+// effacement des clics d'un visiteur, purge définitive d'un lien et anonymisation des
+// anciens clics. Chaque action effective est tracée dans le journal d'audit ; une
+// simulation (dryRun) ne modifie rien et n'est pas tracée.
+type PrivacyService struct {
+	privacyRepo repository.PrivacyRepository
+	auditRepo   repository.AuditRepository
+	anonymizer  *netutil.IPAnonymizer
+}
+
+// NewPrivacyService crée et retourne une nouvelle instance de PrivacyService.
+// anonymizer doit être celui des clics : il permet de retrouver l'empreinte d'une IP en mode hashed.
+func NewPrivacyService(privacyRepo repository.PrivacyRepository, auditRepo repository.AuditRepository, anonymizer *netutil.IPAnonymizer) *PrivacyService {
+	return &PrivacyService{
+		privacyRepo: privacyRepo,
+		auditRepo:   auditRepo,
+		anonymizer:  anonymizer,
+	}
+}
+
+// EraseVisitor supprime les clics d'un visiteur, identifié par son adresse IP ou par son
+// identifiant haché (l'empreinte enregistrée en mode analytics.ip_mode=hashed). Une IP est
+// aussi recherchée sous son empreinte lorsque ce mode est actif. Les adresses tronquées,
+// partagées par plusieurs visiteurs, ne sont pas concernées.
+func (s *PrivacyService) EraseVisitor(actor Actor, ip, visitorID string, dryRun bool) (*PrivacyResult, error) {
+	var values []string
+	if ip != "" {
+		if net.ParseIP(ip) == nil {
+			return nil, ErrInvalidIP
+		}
+		values = append(values, ip)
+		if s.anonymizer.Mode() == netutil.IPModeHashed {
+			values = append(values, s.anonymizer.Anonymize(ip))
+		}
+	}
+	if visitorID != "" {
+		values = append(values, visitorID)
+	}
+	if len(values) == 0 {
+		return nil, ErrNoVisitorCriteria
+	}
+
+	counts, err := s.privacyRepo.EraseVisitor(values, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase visitor data: %w", err)
+	}
+	if !dryRun {
+		// L'identifiant du visiteur n'est pas tracé : le journal ne doit pas le conserver.
+		s.record(models.AuditActionEraseVisitor, actor, nil, counts)
+	}
+	return &PrivacyResult{DryRun: dryRun, Counts: counts}, nil
+}
+
+// PurgeLink supprime définitivement un lien, actif ou déjà supprimé, avec ses clics et toutes
+// ses données rattachées. Seul le journal d'audit du lien est conservé.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien n'utilise ce code court.
+func (s *PrivacyService) PurgeLink(actor Actor, domain, shortCode string, dryRun bool) (*PrivacyResult, error) {
+	link, err := s.privacyRepo.GetLinkIncludingDeleted(domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.privacyRepo.PurgeLink(link, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge link: %w", err)
+	}
+	if !dryRun {
+		s.record(models.AuditActionPurge, actor, link, counts)
+	}
+	return &PrivacyResult{DryRun: dryRun, Counts: counts}, nil
+}
+
+// AnonymizeOlderThan efface l'adresse IP des clics et des signalements de plus de days jours.
+func (s *PrivacyService) AnonymizeOlderThan(actor Actor, days int, dryRun bool) (*PrivacyResult, error) {
+	if days < 1 {
+		return nil, ErrInvalidAge
+	}
+	before := time.Now().AddDate(0, 0, -days)
+	counts, err := s.privacyRepo.AnonymizeBefore(before, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize clicks: %w", err)
+	}
+	if !dryRun {
+		s.record(models.AuditActionAnonymize, actor, nil, counts)
+	}
+	return &PrivacyResult{DryRun: dryRun, Counts: counts}, nil
+}
+
+// record trace une action de protection des données et les décomptes de lignes touchées.
+// link est nil pour les actions qui ne portent pas sur un lien.
+func (s *PrivacyService) record(action string, actor Actor, link *models.Link, counts repository.ErasureCounts) {
+	if s.auditRepo == nil {
+		return
+	}
+	after, _ := json.Marshal(counts) // Une structure d'entiers se sérialise toujours
+	event := &models.AuditEvent{
+		Action: action,
+		Actor:  actor.Name,
+		Source: actor.Source,
+		After:  string(after),
+	}
+	if link != nil {
+		event.LinkID = link.ID
+		event.Domain = link.Domain
+		event.ShortCode = link.Shortcode
+		event.Before = snapshotLink(link)
+	}
+	if err := s.auditRepo.CreateEvent(event); err != nil {
+		log.Printf("[AUDIT] ERREUR lors de l'enregistrement de l'action %s par %s : %v", action, actor.Name, err)
+	}
+}
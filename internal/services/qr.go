@@ -0,0 +1,61 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/qrcode"
+)
+
+// QRSource est la valeur du marqueur ?src= ajouté aux URLs encodées dans les QR codes,
+// pour attribuer les scans dans les statistiques de clics.
+const QRSource = "qr"
+
+// Bornes de la taille des QR codes générés, en pixels.
+const (
+	DefaultQRSize = 256
+	MinQRSize     = 64
+	MaxQRSize     = 2048
+)
+
+var (
+	ErrInvalidQRFormat = errors.New("format must be png or svg")
+	ErrInvalidQRSize   = errors.New("size must be between 64 and 2048 pixels")
+)
+
+// KnownSources sont les valeurs du marqueur ?src= enregistrées avec un clic.
+// Toute autre valeur est ignorée pour ne pas polluer les statistiques.
+var KnownSources = map[string]bool{
+	QRSource: true,
+}
+
+// QRCodeURL retourne l'URL courte complète encodée dans le QR code d'un lien.
+func QRCodeURL(baseURL, shortCode string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + shortCode + "?src=" + QRSource
+}
+
+// RenderQRCode encode content dans un QR code au format "png" ou "svg" et retourne
+// l'image ainsi que son type MIME.
+func RenderQRCode(content, format string, size int, level qrcode.Level) ([]byte, string, error) {
+	if size < MinQRSize || size > MaxQRSize {
+		return nil, "", ErrInvalidQRSize
+	}
+
+	code, err := qrcode.Encode(content, level)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch strings.ToLower(format) {
+	case "png", "":
+		var buf bytes.Buffer
+		if err := code.WritePNG(&buf, size); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	case "svg":
+		return code.SVG(size), "image/svg+xml", nil
+	}
+	return nil, "", ErrInvalidQRFormat
+}
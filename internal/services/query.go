@@ -0,0 +1,128 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// UTMParams regroupe les paramètres de campagne à ajouter à l'URL de destination d'un lien.
+type UTMParams struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// values retourne les paramètres UTM renseignés, indexés par leur nom de paramètre d'URL.
+func (p UTMParams) values() url.Values {
+	v := url.Values{}
+	for name, value := range map[string]string{
+		"utm_source":   p.Source,
+		"utm_medium":   p.Medium,
+		"utm_campaign": p.Campaign,
+		"utm_term":     p.Term,
+		"utm_content":  p.Content,
+	} {
+		if value != "" {
+			v.Set(name, value)
+		}
+	}
+	return v
+}
+
+// ApplyUTM ajoute les paramètres UTM à longURL. Un paramètre UTM déjà présent dans l'URL
+// est remplacé par la valeur fournie ; les autres paramètres et leur ordre sont conservés.
+func ApplyUTM(longURL string, utm UTMParams) (string, error) {
+	params := utm.values()
+	if len(params) == 0 {
+		return longURL, nil
+	}
+
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination URL: %w", err)
+	}
+
+	u.RawQuery = appendQuery(removeQueryKeys(u.RawQuery, params), params)
+	return u.String(), nil
+}
+
+// MergeQuery transfère les paramètres de la requête sur l'URL courte vers l'URL de destination.
+// Règles de fusion :
+//   - un paramètre déjà présent dans la destination n'est jamais écrasé ;
+//   - les paramètres dont le nom figure dans reserved (marqueurs internes au service) ne sont pas transmis ;
+//   - les valeurs multiples d'un même paramètre sont toutes transmises.
+func MergeQuery(destination string, incoming url.Values, reserved map[string]bool) string {
+	if len(incoming) == 0 {
+		return destination
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+	existing := u.Query()
+
+	extra := url.Values{}
+	for key, values := range incoming {
+		if reserved[key] {
+			continue
+		}
+		if _, ok := existing[key]; ok {
+			continue
+		}
+		extra[key] = values
+	}
+	if len(extra) == 0 {
+		return destination
+	}
+
+	u.RawQuery = appendQuery(u.RawQuery, extra)
+	return u.String()
+}
+
+// removeQueryKeys retire de rawQuery les paramètres dont le nom figure dans keys,
+// sans réencoder ni réordonner les autres.
+func removeQueryKeys(rawQuery string, keys url.Values) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, part := range strings.Split(rawQuery, "&") {
+		name := part
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name = part[:i]
+		}
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if _, drop := keys[name]; !drop {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// appendQuery ajoute extra à la fin de rawQuery, par ordre alphabétique des noms.
+func appendQuery(rawQuery string, extra url.Values) string {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(extra))
+	for _, key := range keys {
+		for _, value := range extra[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	if rawQuery == "" {
+		return strings.Join(parts, "&")
+	}
+	return rawQuery + "&" + strings.Join(parts, "&")
+}
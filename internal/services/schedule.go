@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrScheduleInPast est retournée lorsqu'un changement est programmé dans le passé.
+	ErrScheduleInPast = errors.New("scheduled change must be in the future")
+	// ErrScheduleNotFound est retournée lorsqu'un changement programmé n'existe pas pour ce lien.
+	ErrScheduleNotFound = errors.New("scheduled change not found")
+	// ErrScheduleNotPending est retournée lorsqu'un changement déjà traité est annulé.
+	ErrScheduleNotPending = errors.New("scheduled change is no longer pending")
+)
+
+// SchedulerActor est l'auteur des changements de destination appliqués à leur échéance.
+var SchedulerActor = Actor{Name: "scheduler", Source: models.AuditSourceScheduler, Admin: true}
+
+// ScheduleService gère les changements de destination programmés des liens.
+type ScheduleService struct {
+	linkRepo     repository.LinkRepository
+	scheduleRepo repository.ScheduleRepository
+	auditRepo    repository.AuditRepository
+	urlPolicy    *URLPolicy
+	webhooks     *WebhookService
+}
+
+// NewScheduleService crée et retourne une nouvelle instance de ScheduleService.
+// webhooks reçoit l'événement link.updated des changements appliqués, nil pour ne pas en publier.
+func NewScheduleService(linkRepo repository.LinkRepository, scheduleRepo repository.ScheduleRepository, auditRepo repository.AuditRepository, urlPolicy *URLPolicy, webhooks *WebhookService) *ScheduleService {
+	return &ScheduleService{
+		linkRepo:     linkRepo,
+		scheduleRepo: scheduleRepo,
+		auditRepo:    auditRepo,
+		urlPolicy:    urlPolicy,
+		webhooks:     webhooks,
+	}
+}
+
+// ScheduleChange programme le passage de la destination d'un lien à longURL à la date at.
+// Il renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *ScheduleService) ScheduleChange(actor Actor, domain, shortCode string, at time.Time, longURL string) (*models.ScheduledChange, error) {
+	if !at.After(time.Now()) {
+		return nil, ErrScheduleInPast
+	}
+	if err := checkURL(s.urlPolicy, longURL); err != nil {
+		return nil, err
+	}
+
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &models.ScheduledChange{
+		LinkID:    link.ID,
+		At:        at.UTC(), // Les dates sont comparées en SQL : elles sont toutes stockées en UTC
+		LongURL:   longURL,
+		Status:    models.ScheduleStatusPending,
+		CreatedBy: actor.Name,
+	}
+	if err := s.scheduleRepo.CreateChange(change); err != nil {
+		return nil, fmt.Errorf("failed to schedule change: %w", err)
+	}
+	return change, nil
+}
+
+// ListChanges retourne le lien et ses changements programmés, passés et à venir.
+func (s *ScheduleService) ListChanges(actor Actor, domain, shortCode string) (*models.Link, []models.ScheduledChange, error) {
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionView)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := s.scheduleRepo.ListChanges(link.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, changes, nil
+}
+
+// CancelChange annule un changement programmé encore en attente.
+func (s *ScheduleService) CancelChange(actor Actor, domain, shortCode string, id uint) error {
+	link, err := findLink(s.linkRepo, actor, domain, shortCode, PermissionEdit)
+	if err != nil {
+		return err
+	}
+	change, err := s.scheduleRepo.GetChange(link.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrScheduleNotFound
+		}
+		return err
+	}
+
+	cancelled, err := s.scheduleRepo.CancelChange(change)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled change: %w", err)
+	}
+	if !cancelled {
+		return ErrScheduleNotPending
+	}
+	return nil
+}
+
+// ApplyDueChanges applique tous les changements arrivés à échéance, au plus batchSize à la fois,
+// et retourne le nombre de changements appliqués.
+func (s *ScheduleService) ApplyDueChanges(now time.Time, batchSize int) (int, error) {
+	changes, err := s.scheduleRepo.FindDueChanges(now.UTC(), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due changes: %w", err)
+	}
+
+	applied := 0
+	for i := range changes {
+		ok, err := s.apply(&changes[i])
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// RefreshLink applique les changements échus d'un lien au moment d'une redirection, sans
+// attendre le passage du planificateur, puis retourne le lien à jour.
+func (s *ScheduleService) RefreshLink(link *models.Link, now time.Time) (*models.Link, error) {
+	if !link.HasDueChange(now) {
+		return link, nil
+	}
+
+	changes, err := s.scheduleRepo.ListChanges(link.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		change := &changes[i]
+		if change.Status != models.ScheduleStatusPending || change.At.After(now) {
+			continue
+		}
+		if _, err := s.apply(change); err != nil {
+			return nil, err
+		}
+	}
+	return s.linkRepo.GetLinkByShortCode(link.Domain, link.Shortcode)
+}
+
+// apply applique un changement programmé : la destination du lien change et une nouvelle
+// version est créée, à partir de l'état du lien relu au moment de l'écriture. Une destination
+// refusée par la politique d'URLs marque le changement comme échoué. Il retourne false si le
+// changement n'a pas été appliqué.
+func (s *ScheduleService) apply(change *models.ScheduledChange) (bool, error) {
+	link, err := s.linkRepo.GetLinkByID(change.LinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Le lien a été supprimé : le changement n'a plus d'objet.
+			change.Error = "link deleted"
+			return false, s.scheduleRepo.FailChange(change)
+		}
+		return false, err
+	}
+
+	if err := checkURL(s.urlPolicy, change.LongURL); err != nil {
+		log.Printf("[SCHEDULER] Changement #%d du lien %s refusé : %v", change.ID, link.Shortcode, err)
+		change.Error = err.Error()
+		return false, s.scheduleRepo.FailChange(change)
+	}
+
+	before, after, err := s.scheduleRepo.ApplyChange(change, SchedulerActor.Name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			change.Error = "link deleted"
+			return false, s.scheduleRepo.FailChange(change)
+		}
+		return false, fmt.Errorf("failed to apply scheduled change #%d: %w", change.ID, err)
+	}
+	if after == nil {
+		return false, nil // Déjà traité par ailleurs
+	}
+
+	log.Printf("[SCHEDULER] Lien %s : destination changée vers %s (programmée pour %s).",
+		after.Shortcode, change.LongURL, change.At.Format(time.RFC3339))
+	recordAudit(s.auditRepo, models.AuditActionUpdate, SchedulerActor, after, snapshotLink(before), snapshotLink(after))
+	s.webhooks.publishLink(models.AuditActionUpdate, SchedulerActor, after)
+	return true, nil
+}
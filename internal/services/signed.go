@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/signing"
+)
+
+var (
+	ErrLinkNotSigned = errors.New("link is not in signed mode")
+	ErrInvalidTTL    = errors.New("ttl must be positive and within the configured maximum")
+)
+
+// SigningSubject retourne la chaîne couverte par la signature d'un lien. Le domaine en fait
+// partie afin qu'une URL signée ne soit pas valable pour le même code sur un autre domaine ;
+// les liens du domaine principal gardent le code seul, ce qui préserve les URLs déjà émises.
+func SigningSubject(link *models.Link) string {
+	if link.Domain == "" {
+		return link.Shortcode
+	}
+	return link.Domain + "/" + link.Shortcode
+}
+
+// SignedShortURL retourne l'URL courte signée d'un lien, valable pendant ttl.
+// L'URL porte sa date d'expiration (exp) et sa signature (sig) : aucun état n'est stocké.
+func SignedShortURL(signer *signing.Signer, baseURL string, link *models.Link, ttl, maxTTL time.Duration) (string, time.Time, error) {
+	if !link.SignedOnly {
+		return "", time.Time{}, ErrLinkNotSigned
+	}
+	if ttl <= 0 || (maxTTL > 0 && ttl > maxTTL) {
+		return "", time.Time{}, ErrInvalidTTL
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	sig, err := signer.Sign(SigningSubject(link), expires)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	query := url.Values{}
+	query.Set("exp", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("sig", sig)
+	return strings.TrimRight(baseURL, "/") + "/" + link.Shortcode + "?" + query.Encode(), expires, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/useragent"
+)
+
+// maxRules est le nombre maximal de règles de ciblage d'un lien.
+const maxRules = 20
+
+// ErrInvalidRules est retournée lorsque les règles de ciblage d'un lien sont incohérentes.
+var ErrInvalidRules = errors.New("invalid targeting rules")
+
+// languageTag valide une langue de règle : code ISO 639 suivi d'une éventuelle région (ex: "fr", "pt-br").
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// countryCode valide un pays de règle : code ISO 3166-1 alpha-2 (ex: "FR").
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Rule décrit une règle de ciblage d'un lien, telle que saisie par l'API ou la CLI.
+type Rule struct {
+	Priority int    `json:"priority"`
+	OS       string `json:"os,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Language string `json:"language,omitempty"`
+	Country  string `json:"country,omitempty"`
+	LongURL  string `json:"long_url"`
+}
+
+// Visitor regroupe les caractéristiques d'un visiteur sur lesquelles portent les règles de ciblage.
+type Visitor struct {
+	OS       string
+	Device   string
+	Language string // Langue préférée, en minuscules (ex: "fr-ca"), vide si inconnue
+	Country  string // Pays d'après la base GeoIP (ex: "FR"), vide si inconnu
+}
+
+// NewVisitor construit un Visitor à partir des en-têtes User-Agent et Accept-Language d'une requête.
+// Le pays, qui dépend de la base GeoIP, est renseigné par l'appelant.
+func NewVisitor(userAgent, acceptLanguage string) Visitor {
+	info := useragent.Parse(userAgent)
+	return Visitor{
+		OS:       info.OS,
+		Device:   info.Device,
+		Language: PreferredLanguage(acceptLanguage),
+	}
+}
+
+// PreferredLanguage retourne la langue de plus forte préférence d'un en-tête Accept-Language,
+// en minuscules, ou une chaîne vide si l'en-tête est absent ou ne désigne aucune langue.
+func PreferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// À préférence égale, la première langue annoncée l'emporte.
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// ruleMatches indique si le visiteur correspond à tous les critères renseignés de la règle.
+// Une règle de langue "fr" correspond aux visiteurs "fr", "fr-fr", "fr-ca", etc.
+func ruleMatches(rule *models.TargetingRule, v Visitor) bool {
+	if rule.OS != "" && rule.OS != v.OS {
+		return false
+	}
+	if rule.Device != "" && rule.Device != v.Device {
+		return false
+	}
+	if rule.Language != "" && v.Language != rule.Language && !strings.HasPrefix(v.Language, rule.Language+"-") {
+		return false
+	}
+	if rule.Country != "" && rule.Country != v.Country {
+		return false
+	}
+	return true
+}
+
+// MatchRule retourne la première règle du lien, par priorité croissante, à laquelle correspond
+// le visiteur, ou nil si aucune ne correspond et que la destination par défaut s'applique.
+func MatchRule(link *models.Link, v Visitor) *models.TargetingRule {
+	for i := range link.Rules {
+		if ruleMatches(&link.Rules[i], v) {
+			return &link.Rules[i]
+		}
+	}
+	return nil
+}
+
+// rulesOf retourne les règles de ciblage d'un lien sous leur forme saisie.
+func rulesOf(link *models.Link) []Rule {
+	if !link.HasRules() {
+		return nil
+	}
+	rules := make([]Rule, 0, len(link.Rules))
+	for _, r := range link.Rules {
+		rules = append(rules, Rule{Priority: r.Priority, OS: r.OS, Device: r.Device, Language: r.Language, Country: r.Country, LongURL: r.LongURL})
+	}
+	return rules
+}
+
+// buildRules valide les règles saisies et les convertit en modèles, triées dans leur ordre
+// d'évaluation. Une liste vide est valide : elle retire toutes les règles du lien.
+func (s *LinkService) buildRules(inputs []Rule) ([]models.TargetingRule, error) {
+	if len(inputs) > maxRules {
+		return nil, fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidRules, maxRules)
+	}
+
+	rules := make([]models.TargetingRule, 0, len(inputs))
+	for i, in := range inputs {
+		rule := models.TargetingRule{
+			Priority: in.Priority,
+			OS:       strings.ToLower(in.OS),
+			Device:   strings.ToLower(in.Device),
+			Language: strings.ToLower(in.Language),
+			Country:  strings.ToUpper(in.Country),
+			LongURL:  in.LongURL,
+		}
+		if rule.OS == "" && rule.Device == "" && rule.Language == "" && rule.Country == "" {
+			return nil, fmt.Errorf("%w: rule %d has no criteria", ErrInvalidRules, i+1)
+		}
+		if rule.OS != "" && !useragent.IsKnownOS(rule.OS) {
+			return nil, fmt.Errorf("%w: unknown os %q", ErrInvalidRules, in.OS)
+		}
+		if rule.Device != "" && !useragent.IsKnownDevice(rule.Device) {
+			return nil, fmt.Errorf("%w: unknown device %q", ErrInvalidRules, in.Device)
+		}
+		if rule.Language != "" && !languageTag.MatchString(rule.Language) {
+			return nil, fmt.Errorf("%w: invalid language %q", ErrInvalidRules, in.Language)
+		}
+		if rule.Country != "" && !countryCode.MatchString(rule.Country) {
+			return nil, fmt.Errorf("%w: invalid country %q", ErrInvalidRules, in.Country)
+		}
+		if err := s.checkURL(rule.LongURL); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	// À priorité égale, les règles sont évaluées dans l'ordre de saisie.
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+	return rules, nil
+}
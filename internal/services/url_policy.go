@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+)
+
+// ErrURLRejected est retournée (enveloppée avec la raison du refus) lorsqu'une URL de
+// destination ne respecte pas la politique de sécurité des URLs.
+var ErrURLRejected = errors.New("destination URL rejected")
+
+// URLPolicy contrôle les URLs de destination avant qu'elles ne soient raccourcies :
+// schémas autorisés, cibles internes, listes de domaines autorisés/interdits, liste de
+// blocage chargée depuis un fichier et liens pointant vers le service lui-même.
+type URLPolicy struct {
+	allowedSchemes map[string]bool
+	allowDomains   []string
+	denyDomains    []string
+	selfHosts      []string
+	blockPrivate   bool
+	resolve        bool
+	lookupIP       func(host string) ([]net.IP, error)
+
+	blocklistFile string
+	mu            sync.RWMutex
+	blocklist     map[string]bool
+	blocklistMod  time.Time
+}
+
+// NewURLPolicy crée la politique de sécurité des URLs à partir de la configuration
+// et charge la liste de blocage si un fichier est configuré.
+func NewURLPolicy(cfg *config.Config) (*URLPolicy, error) {
+	pc := cfg.URLPolicy
+	p := &URLPolicy{
+		allowedSchemes: make(map[string]bool),
+		allowDomains:   normalizeDomains(pc.AllowDomains),
+		denyDomains:    normalizeDomains(pc.DenyDomains),
+		blockPrivate:   pc.BlockPrivateAddresses,
+		resolve:        pc.ResolveHostnames,
+		lookupIP:       net.LookupIP,
+		blocklistFile:  pc.BlocklistFile,
+		blocklist:      make(map[string]bool),
+	}
+	for _, scheme := range pc.AllowedSchemes {
+		p.allowedSchemes[strings.ToLower(scheme)] = true
+	}
+	// Un lien vers l'un des domaines courts du service créerait une boucle de redirections.
+	p.selfHosts = cfg.DomainHosts()
+
+	if p.blocklistFile != "" {
+		if err := p.reloadBlocklist(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Check vérifie qu'une URL de destination respecte la politique.
+// L'erreur retournée enveloppe ErrURLRejected et précise la raison du refus.
+func (p *URLPolicy) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return reject("invalid URL")
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if !p.allowedSchemes[scheme] {
+		return reject(fmt.Sprintf("scheme %q is not allowed", scheme))
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return reject("missing host")
+	}
+
+	for _, self := range p.selfHosts {
+		if host == self {
+			return reject("links to this service are not allowed")
+		}
+	}
+
+	if len(p.allowDomains) > 0 && !matchesAnyDomain(host, p.allowDomains) {
+		return reject(fmt.Sprintf("domain %q is not in the allow list", host))
+	}
+	if matchesAnyDomain(host, p.denyDomains) {
+		return reject(fmt.Sprintf("domain %q is denied", host))
+	}
+	if p.isBlocklisted(host) {
+		return reject(fmt.Sprintf("domain %q is blocklisted", host))
+	}
+
+	if p.blockPrivate {
+		if err := p.checkAddress(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddress refuse les hôtes qui sont, ou qui résolvent vers, des adresses non publiques.
+func (p *URLPolicy) checkAddress(host string) error {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		var numeric bool
+		if ip, numeric = parseIPv4Host(host); numeric && ip == nil {
+			return reject(fmt.Sprintf("host %q is not a valid IPv4 address", host))
+		}
+	}
+	if ip != nil {
+		if !netutil.IsPublicIP(ip) {
+			return reject(fmt.Sprintf("address %s is not public", ip))
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return reject("localhost is not allowed")
+	}
+	if !p.resolve {
+		return nil
+	}
+
+	// Un échec de résolution n'est pas bloquant : le domaine peut exister plus tard,
+	// et les requêtes sortantes vérifient de nouveau l'adresse au moment de la connexion.
+	ips, err := p.lookupIP(host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if !netutil.IsPublicIP(ip) {
+			return reject(fmt.Sprintf("host %q resolves to non-public address %s", host, ip))
+		}
+	}
+	return nil
+}
+
+// parseIPv4Host lit un hôte IPv4 écrit sous une forme que les navigateurs acceptent mais pas
+// net.ParseIP, comme inet_aton : de une à quatre parties décimales, octales (préfixe 0) ou
+// hexadécimales (préfixe 0x), la dernière couvrant les octets restants ("127.1", "2130706433",
+// "0x7f.0.0.1"). numeric indique que le dernier label est un nombre : le navigateur traite
+// alors l'hôte comme une adresse IPv4, et ip vaut nil si elle est invalide.
+func parseIPv4Host(host string) (ip net.IP, numeric bool) {
+	parts := strings.Split(host, ".")
+	if !isNumericLabel(parts[len(parts)-1]) {
+		return nil, false
+	}
+	if len(parts) > 4 {
+		return nil, true
+	}
+
+	var addr uint64
+	for i, part := range parts {
+		n, ok := parseIPv4Part(part)
+		if !ok {
+			return nil, true
+		}
+		if i < len(parts)-1 {
+			if n > 0xff {
+				return nil, true
+			}
+			addr = addr<<8 | n
+			continue
+		}
+		// La dernière partie couvre les octets que les précédentes n'ont pas fixés.
+		rest := uint(8 * (5 - len(parts)))
+		if n >= 1<<rest {
+			return nil, true
+		}
+		addr = addr<<rest | n
+	}
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr)), true
+}
+
+// isNumericLabel indique si un label est un nombre décimal ou hexadécimal (préfixe 0x).
+func isNumericLabel(label string) bool {
+	digits := "0123456789"
+	if strings.HasPrefix(label, "0x") {
+		label, digits = label[2:], "0123456789abcdef"
+		if label == "" {
+			return true
+		}
+	}
+	return label != "" && strings.Trim(label, digits) == ""
+}
+
+// parseIPv4Part lit une partie d'adresse IPv4 en décimal, en octal ou en hexadécimal.
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x"):
+		base, part = 16, part[2:]
+		if part == "" {
+			return 0, true // "0x" seul vaut 0
+		}
+	case len(part) > 1 && part[0] == '0':
+		base, part = 8, part[1:]
+	}
+	if part == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(part, base, 64)
+	return n, err == nil
+}
+
+// StartBlocklistReload surveille le fichier de liste de blocage et le recharge dès que
+// sa date de modification change. Elle est bloquante et doit être appelée dans une goroutine.
+func (p *URLPolicy) StartBlocklistReload(interval time.Duration) {
+	if p.blocklistFile == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(p.blocklistFile)
+		if err != nil {
+			log.Printf("[URL POLICY] Impossible de lire la liste de blocage '%s' : %v", p.blocklistFile, err)
+			continue
+		}
+
+		p.mu.RLock()
+		unchanged := info.ModTime().Equal(p.blocklistMod)
+		p.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		if err := p.reloadBlocklist(); err != nil {
+			log.Printf("[URL POLICY] ERREUR lors du rechargement de la liste de blocage : %v", err)
+		}
+	}
+}
+
+// reloadBlocklist lit le fichier de liste de blocage : un domaine par ligne,
+// les lignes vides et celles commençant par '#' sont ignorées.
+func (p *URLPolicy) reloadBlocklist() error {
+	f, err := os.Open(p.blocklistFile)
+	if err != nil {
+		return fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat blocklist: %w", err)
+	}
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.TrimSuffix(strings.ToLower(line), ".")] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	p.mu.Lock()
+	p.blocklist = domains
+	p.blocklistMod = info.ModTime()
+	p.mu.Unlock()
+
+	log.Printf("[URL POLICY] Liste de blocage chargée : %d domaine(s).", len(domains))
+	return nil
+}
+
+// isBlocklisted indique si host ou l'un de ses domaines parents figure dans la liste de blocage.
+func (p *URLPolicy) isBlocklisted(host string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for candidate := host; candidate != ""; {
+		if p.blocklist[candidate] {
+			return true
+		}
+		i := strings.IndexByte(candidate, '.')
+		if i < 0 {
+			break
+		}
+		candidate = candidate[i+1:]
+	}
+	return false
+}
+
+// matchesAnyDomain indique si host est l'un des domaines ou l'un de leurs sous-domaines.
+func matchesAnyDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if d := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), "."); d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// checkURL applique une politique d'URLs facultative : une politique nil accepte toute URL.
+func checkURL(policy *URLPolicy, longURL string) error {
+	if policy == nil {
+		return nil
+	}
+	return policy.Check(longURL)
+}
+
+func reject(reason string) error {
+	return fmt.Errorf("%w: %s", ErrURLRejected, reason)
+}
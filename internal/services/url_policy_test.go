@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// newTestURLPolicy crée une politique qui bloque les adresses non publiques et résout les
+// hôtes avec lookup au lieu du DNS.
+func newTestURLPolicy(t *testing.T, lookup func(host string) ([]net.IP, error)) *URLPolicy {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.URLPolicy.AllowedSchemes = []string{"http", "https"}
+	cfg.URLPolicy.BlockPrivateAddresses = true
+	cfg.URLPolicy.ResolveHostnames = true
+	p, err := NewURLPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewURLPolicy: %v", err)
+	}
+	p.lookupIP = lookup
+	return p
+}
+
+func TestURLPolicyBlocksNonPublicAddresses(t *testing.T) {
+	lookups := 0
+	p := newTestURLPolicy(t, func(host string) ([]net.IP, error) {
+		lookups++
+		switch host {
+		case "intranet.example":
+			return []net.IP{net.IPv4(10, 0, 0, 1)}, nil
+		case "public.example":
+			return []net.IP{net.IPv4(93, 184, 216, 34)}, nil
+		}
+		return nil, errors.New("no such host")
+	})
+
+	cases := []struct {
+		url     string
+		blocked bool
+	}{
+		{"http://127.0.0.1/", true},
+		{"http://[::1]/", true},
+		{"http://localhost/", true},
+		{"http://app.localhost/", true},
+		// Formes IPv4 abrégées, décimales, octales et hexadécimales que les navigateurs
+		// normalisent vers une adresse interne.
+		{"http://127.1/", true},
+		{"http://2130706433/", true},
+		{"http://0177.0.0.1/", true},
+		{"http://0x7f.0.0.1/", true},
+		{"http://0x7f000001/", true},
+		{"http://10.1/", true},
+		{"http://192.168.257/", true},
+		{"http://0/", true},
+		{"http://127.0.0.1./", true},
+		// Hôtes numériques invalides : le navigateur refuse l'URL, la politique aussi.
+		{"http://4294967296/", true},
+		{"http://1.2.3.256/", true},
+		{"http://1.2.3.4.5/", true},
+		{"http://09.1.1.1/", true},
+		{"http://foo.123/", true},
+		// Noms résolus vers une adresse interne.
+		{"http://intranet.example/", true},
+		// Adresses publiques, y compris sous une forme non canonique.
+		{"http://8.8.8.8/", false},
+		{"http://134744072/", false},
+		{"http://0x8.0x8.0x8.0x8/", false},
+		{"http://public.example/", false},
+		{"http://123.example/", false},
+		// Un échec de résolution laisse passer le domaine : il peut exister plus tard, et les
+		// requêtes sortantes vérifient de nouveau l'adresse à la connexion.
+		{"http://unresolved.example/", false},
+	}
+	for _, tc := range cases {
+		err := p.Check(tc.url)
+		if tc.blocked && !errors.Is(err, ErrURLRejected) {
+			t.Errorf("%s: expected ErrURLRejected, got %v", tc.url, err)
+		}
+		if !tc.blocked && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.url, err)
+		}
+	}
+	if lookups != 4 {
+		t.Errorf("expected 4 DNS lookups (named hosts only), got %d", lookups)
+	}
+}
+
+func TestURLPolicyDoesNotResolveWhenDisabled(t *testing.T) {
+	p := newTestURLPolicy(t, func(host string) ([]net.IP, error) {
+		t.Fatalf("unexpected lookup of %s", host)
+		return nil, nil
+	})
+	p.resolve = false
+
+	if err := p.Check("http://intranet.example/"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := p.Check("http://127.1/"); !errors.Is(err, ErrURLRejected) {
+		t.Errorf("127.1: expected ErrURLRejected, got %v", err)
+	}
+}
+
+func TestParseIPv4Host(t *testing.T) {
+	cases := []struct {
+		host    string
+		want    string // Adresse attendue, vide pour une adresse invalide
+		numeric bool
+	}{
+		{"127.1", "127.0.0.1", true},
+		{"2130706433", "127.0.0.1", true},
+		{"0177.0.0.1", "127.0.0.1", true},
+		{"0x7f.0.0.1", "127.0.0.1", true},
+		{"0x7f.1", "127.0.0.1", true},
+		{"10.1", "10.0.0.1", true},
+		{"169.254.43518", "169.254.169.254", true},
+		{"0x", "0.0.0.0", true},
+		{"256.1", "", true},
+		{"1.2.3.4.5", "", true},
+		{"1..1", "", true},
+		{"08", "", true},
+		{"example.com", "", false},
+		{"1.2.3.com", "", false},
+		{"0xg", "", false},
+	}
+	for _, tc := range cases {
+		ip, numeric := parseIPv4Host(tc.host)
+		if numeric != tc.numeric {
+			t.Errorf("%s: numeric = %v, want %v", tc.host, numeric, tc.numeric)
+		}
+		got := ""
+		if ip != nil {
+			got = ip.String()
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.host, got, tc.want)
+		}
+	}
+}
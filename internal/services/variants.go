@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Limites des variantes A/B d'un lien.
+const (
+	maxVariants      = 10
+	maxVariantWeight = 1000
+	maxVariantName   = 50
+)
+
+// ErrInvalidVariants est retournée lorsque les variantes A/B d'un lien sont incohérentes.
+var ErrInvalidVariants = errors.New("invalid variants")
+
+// Variant décrit une destination pondérée d'un lien, telle que saisie par l'API ou la CLI.
+type Variant struct {
+	Name    string `json:"name"`
+	LongURL string `json:"long_url"`
+	Weight  int    `json:"weight"`
+}
+
+// variantsOf retourne les variantes d'un lien sous leur forme saisie.
+func variantsOf(link *models.Link) []Variant {
+	if !link.HasVariants() {
+		return nil
+	}
+	variants := make([]Variant, 0, len(link.Variants))
+	for _, v := range link.Variants {
+		variants = append(variants, Variant{Name: v.Name, LongURL: v.LongURL, Weight: v.Weight})
+	}
+	return variants
+}
+
+// buildVariants valide les variantes saisies et les convertit en modèles.
+// Une liste vide est valide : elle retire toutes les variantes du lien.
+func (s *LinkService) buildVariants(inputs []Variant) ([]models.LinkVariant, error) {
+	if len(inputs) > maxVariants {
+		return nil, fmt.Errorf("%w: at most %d variants are allowed", ErrInvalidVariants, maxVariants)
+	}
+	if len(inputs) == 1 {
+		return nil, fmt.Errorf("%w: at least 2 variants are required to split traffic", ErrInvalidVariants)
+	}
+
+	seen := make(map[string]bool, len(inputs))
+	variants := make([]models.LinkVariant, 0, len(inputs))
+	for _, in := range inputs {
+		if in.Name == "" || len(in.Name) > maxVariantName {
+			return nil, fmt.Errorf("%w: variant name must be between 1 and %d characters", ErrInvalidVariants, maxVariantName)
+		}
+		if seen[in.Name] {
+			return nil, fmt.Errorf("%w: duplicate variant name %q", ErrInvalidVariants, in.Name)
+		}
+		seen[in.Name] = true
+		if in.Weight < 1 || in.Weight > maxVariantWeight {
+			return nil, fmt.Errorf("%w: weight of variant %q must be between 1 and %d", ErrInvalidVariants, in.Name, maxVariantWeight)
+		}
+		if err := s.checkURL(in.LongURL); err != nil {
+			return nil, fmt.Errorf("variant %q: %w", in.Name, err)
+		}
+		variants = append(variants, models.LinkVariant{Name: in.Name, LongURL: in.LongURL, Weight: in.Weight})
+	}
+	return variants, nil
+}
+
+// ChooseVariant tire une variante au hasard, proportionnellement à son poids.
+// Le tirage n'a pas besoin d'être imprévisible : math/rand suffit.
+func ChooseVariant(variants []models.LinkVariant) *models.LinkVariant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	n := rand.IntN(total)
+	for i := range variants {
+		n -= variants[i].Weight
+		if n < 0 {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+// FindVariant retourne la variante du lien portant ce nom, ou nil si elle n'existe plus.
+func FindVariant(link *models.Link, name string) *models.LinkVariant {
+	for i := range link.Variants {
+		if link.Variants[i].Name == name {
+			return &link.Variants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Erreurs de validation retournées par WebhookService.
+var (
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvents = errors.New("events must list at least one of link.created, link.updated, link.deleted, click.recorded, link.health_changed")
+	ErrInvalidWebhookSecret = errors.New("webhook secret must be 16 to 100 characters")
+)
+
+// WebhookEnvelope est le corps JSON envoyé aux webhooks.
+type WebhookEnvelope struct {
+	ID        string    `json:"id"` // Identifiant de l'événement, identique à chaque tentative
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// LinkEventData est le contenu des événements link.created, link.updated et link.deleted.
+type LinkEventData struct {
+	LinkID    uint   `json:"link_id"`
+	Domain    string `json:"domain"`
+	ShortCode string `json:"short_code"`
+	LongURL   string `json:"long_url"`
+	Status    string `json:"status"`
+	Version   int    `json:"version"`
+	Action    string `json:"action"` // Action du journal d'audit : create, update, rollback, disable...
+	Actor     string `json:"actor"`
+}
+
+// ClickEventData est le contenu de l'événement click.recorded. L'adresse IP du visiteur
+// n'y figure pas.
+type ClickEventData struct {
+	LinkID    uint      `json:"link_id"`
+	Domain    string    `json:"domain"`
+	ShortCode string    `json:"short_code"`
+	Timestamp time.Time `json:"timestamp"`
+	Referrer  string    `json:"referrer"`
+	Source    string    `json:"source"`
+	Country   string    `json:"country"`
+	Version   int       `json:"version"`
+	Variant   string    `json:"variant"`
+}
+
+// HealthEventData est le contenu de l'événement link.health_changed, émis par le moniteur d'URLs.
+type HealthEventData struct {
+	LinkID     uint   `json:"link_id"`
+	Domain     string `json:"domain"`
+	ShortCode  string `json:"short_code"`
+	LongURL    string `json:"long_url"`
+	Accessible bool   `json:"accessible"`
+}
+
+// linkEvents associe les actions du journal d'audit aux événements de webhook.
+var linkEvents = map[string]string{
+	models.AuditActionCreate:   models.WebhookEventLinkCreated,
+	models.AuditActionUpdate:   models.WebhookEventLinkUpdated,
+	models.AuditActionRollback: models.WebhookEventLinkUpdated,
+	models.AuditActionEnable:   models.WebhookEventLinkUpdated,
+	models.AuditActionDisable:  models.WebhookEventLinkUpdated,
+	models.AuditActionBlock:    models.WebhookEventLinkUpdated,
+	models.AuditActionDelete:   models.WebhookEventLinkDeleted,
+}
+
+// WebhookService gère les abonnements aux webhooks et met en file les événements publiés.
+// Les envois sont effectués par le webhooks.Dispatcher du serveur, qui lit la file en base :
+// les événements publiés par la CLI sont donc aussi livrés, dès que le serveur tourne.
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	pending     chan struct{}
+}
+
+// NewWebhookService crée et retourne une nouvelle instance de WebhookService.
+func NewWebhookService(webhookRepo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		pending:     make(chan struct{}, 1),
+	}
+}
+
+// Pending est signalé lorsqu'un événement a été mis en file, pour que le Dispatcher
+// n'attende pas son prochain passage.
+func (s *WebhookService) Pending() <-chan struct{} {
+	return s.pending
+}
+
+// CreateWebhook abonne url aux événements listés. Sans secret fourni, un secret aléatoire
+// est généré ; il est retourné dans le webhook créé.
+func (s *WebhookService) CreateWebhook(rawURL, secret string, events []string) (*models.Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	events, err = normalizeEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		if secret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < 16 || len(secret) > 100 {
+		return nil, ErrInvalidWebhookSecret
+	}
+
+	webhook := &models.Webhook{URL: rawURL, Secret: secret, Events: strings.Join(events, ",")}
+	if err := s.webhookRepo.CreateWebhook(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retourne tous les abonnements.
+func (s *WebhookService) ListWebhooks() ([]models.Webhook, error) {
+	return s.webhookRepo.ListWebhooks()
+}
+
+// GetWebhook récupère un abonnement. Il renvoie gorm.ErrRecordNotFound s'il n'existe pas.
+func (s *WebhookService) GetWebhook(id uint) (*models.Webhook, error) {
+	return s.webhookRepo.GetWebhook(id)
+}
+
+// DeleteWebhook supprime un abonnement, ses envois en attente et ses lettres mortes.
+// Il renvoie gorm.ErrRecordNotFound s'il n'existe pas.
+func (s *WebhookService) DeleteWebhook(id uint) error {
+	webhook, err := s.webhookRepo.GetWebhook(id)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteWebhook(webhook); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retourne les envois abandonnés, les plus récents en premier.
+// webhookID restreint la liste à un abonnement s'il est non nul.
+func (s *WebhookService) ListDeadLetters(webhookID uint, limit int) ([]models.WebhookDeadLetter, error) {
+	return s.webhookRepo.ListDeadLetters(webhookID, limit)
+}
+
+// Redeliver remet un envoi abandonné dans la file, avec un compteur de tentatives à zéro.
+// Il renvoie gorm.ErrRecordNotFound si la lettre morte n'existe pas.
+func (s *WebhookService) Redeliver(id uint) (*models.WebhookDelivery, error) {
+	dead, err := s.webhookRepo.GetDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &models.WebhookDelivery{
+		WebhookID:     dead.WebhookID,
+		EventID:       dead.EventID,
+		Event:         dead.Event,
+		Payload:       dead.Payload,
+		NextAttemptAt: time.Now().UTC(),
+	}
+	if err := s.webhookRepo.RequeueDeadLetter(dead, delivery); err != nil {
+		return nil, err
+	}
+	s.signal()
+	return delivery, nil
+}
+
+// Publish met en file l'événement pour chaque webhook abonné. Les événements sont publiés
+// après l'action qu'ils décrivent : un échec est signalé dans les logs sans l'annuler.
+// Un WebhookService nil ne publie rien.
+func (s *WebhookService) Publish(event string, data any) {
+	if s == nil {
+		return
+	}
+	webhooks, err := s.webhookRepo.ListWebhooks()
+	if err != nil {
+		log.Printf("[WEBHOOK] ERREUR lors de la lecture des abonnements pour %s : %v", event, err)
+		return
+	}
+	var subscribers []models.Webhook
+	for _, webhook := range webhooks {
+		if subscribes(webhook, event) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		log.Printf("[WEBHOOK] ERREUR lors de la génération de l'identifiant de %s : %v", event, err)
+		return
+	}
+	now := time.Now().UTC() // Les échéances sont comparées en SQL : elles sont toutes stockées en UTC
+	payload, err := json.Marshal(WebhookEnvelope{ID: id, Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		log.Printf("[WEBHOOK] ERREUR lors de la sérialisation de %s : %v", event, err)
+		return
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(subscribers))
+	for _, webhook := range subscribers {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       id,
+			Event:         event,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+		})
+	}
+	if err := s.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		log.Printf("[WEBHOOK] ERREUR lors de la mise en file de %s : %v", event, err)
+		return
+	}
+	s.signal()
+}
+
+// publishLink publie l'événement correspondant à une action du journal d'audit sur un lien.
+func (s *WebhookService) publishLink(action string, actor Actor, link *models.Link) {
+	event, ok := linkEvents[action]
+	if !ok {
+		return
+	}
+	s.Publish(event, LinkEventData{
+		LinkID:    link.ID,
+		Domain:    link.Domain,
+		ShortCode: link.Shortcode,
+		LongURL:   link.LongURL,
+		Status:    link.Status,
+		Version:   link.Version,
+		Action:    action,
+		Actor:     actor.Name,
+	})
+}
+
+// signal réveille le Dispatcher sans bloquer si un réveil est déjà en attente.
+func (s *WebhookService) signal() {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+}
+
+// subscribes indique si un webhook est abonné à event.
+func subscribes(webhook models.Webhook, event string) bool {
+	for _, e := range strings.Split(webhook.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEvents valide les événements demandés et retire les doublons.
+func normalizeEvents(events []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEvent(event) {
+			return nil, ErrInvalidWebhookEvents
+		}
+		if !seen[event] {
+			seen[event] = true
+			result = append(result, event)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+	return result, nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range models.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
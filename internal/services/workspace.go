@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Erreurs retournées par la gestion des espaces de travail et le contrôle d'accès.
+var (
+	ErrForbidden        = errors.New("permission denied")
+	ErrInvalidWorkspace = errors.New("workspace slug must be 1 to 50 lowercase letters, digits or dashes")
+	ErrInvalidRole      = errors.New("role must be one of owner, editor or viewer")
+	ErrInvalidMember    = errors.New("member must be key:<API key name> or user:<JWT user> of at most 100 characters")
+	ErrWorkspaceExists  = errors.New("workspace already exists")
+	ErrLastOwner        = errors.New("a workspace must keep at least one owner")
+	ErrNotMember        = errors.New("not a member of this workspace")
+)
+
+// Permission est un droit d'accès aux liens d'un espace de travail.
+type Permission int
+
+const (
+	PermissionView   Permission = iota // Consulter un lien, ses versions et ses statistiques
+	PermissionEdit                     // Créer, modifier, signer, programmer et supprimer des liens
+	PermissionManage                   // Gérer les membres de l'espace
+)
+
+// workspaceSlugPattern décrit les identifiants d'espace de travail acceptés.
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// IsValidRole indique si role est un rôle d'espace de travail connu.
+func IsValidRole(role string) bool {
+	switch role {
+	case models.WorkspaceRoleOwner, models.WorkspaceRoleEditor, models.WorkspaceRoleViewer:
+		return true
+	}
+	return false
+}
+
+// RoleAllows indique si un rôle accorde une permission.
+func RoleAllows(role string, perm Permission) bool {
+	switch role {
+	case models.WorkspaceRoleOwner:
+		return true
+	case models.WorkspaceRoleEditor:
+		return perm <= PermissionEdit
+	case models.WorkspaceRoleViewer:
+		return perm == PermissionView
+	}
+	return false
+}
+
+// authorize vérifie qu'un auteur peut exercer une permission sur un lien. Les liens qui
+// n'appartiennent à aucun espace sont réservés aux administrateurs, les autres clients ne
+// pouvant au plus que les consulter (Actor.SharedView). Un lien d'un autre espace est signalé
+// comme introuvable (gorm.ErrRecordNotFound), pour ne pas révéler son existence.
+func authorize(actor Actor, link *models.Link, perm Permission) error {
+	if actor.Admin {
+		return nil
+	}
+	if link.WorkspaceID == 0 {
+		if perm == PermissionView && actor.SharedView {
+			return nil
+		}
+		return ErrForbidden
+	}
+	if link.WorkspaceID != actor.WorkspaceID {
+		return gorm.ErrRecordNotFound
+	}
+	if !RoleAllows(actor.Role, perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeCreate vérifie qu'un auteur peut créer un lien dans son espace de travail.
+func authorizeCreate(actor Actor) error {
+	if actor.Admin || actor.WorkspaceID == 0 || RoleAllows(actor.Role, PermissionEdit) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// WorkspaceService gère les espaces de travail et l'appartenance des clients de l'API.
+type WorkspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+}
+
+// NewWorkspaceService crée et retourne une nouvelle instance de WorkspaceService.
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository) *WorkspaceService {
+	return &WorkspaceService{workspaceRepo: workspaceRepo}
+}
+
+// CreateWorkspace crée un espace de travail. owner, s'il est fourni, en devient le premier propriétaire.
+func (s *WorkspaceService) CreateWorkspace(slug, name, owner string) (*models.Workspace, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !workspaceSlugPattern.MatchString(slug) {
+		return nil, ErrInvalidWorkspace
+	}
+	if name == "" {
+		name = slug
+	}
+	if _, err := s.workspaceRepo.GetWorkspaceBySlug(slug); err == nil {
+		return nil, ErrWorkspaceExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	workspace := &models.Workspace{Slug: slug, Name: name}
+	if owner != "" {
+		if !isValidMember(owner) {
+			return nil, ErrInvalidMember
+		}
+		workspace.Members = []models.WorkspaceMember{{Member: owner, Role: models.WorkspaceRoleOwner}}
+	}
+	if err := s.workspaceRepo.CreateWorkspace(workspace); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces retourne tous les espaces de travail.
+func (s *WorkspaceService) ListWorkspaces() ([]models.Workspace, error) {
+	return s.workspaceRepo.ListWorkspaces()
+}
+
+// GetWorkspace récupère un espace de travail par son slug.
+// Il renvoie gorm.ErrRecordNotFound si l'espace n'existe pas.
+func (s *WorkspaceService) GetWorkspace(slug string) (*models.Workspace, error) {
+	return s.workspaceRepo.GetWorkspaceBySlug(strings.ToLower(slug))
+}
+
+// Membership retourne l'espace de travail désigné par slug et le rôle qu'y tient member.
+// Il renvoie ErrNotMember si l'espace n'existe pas ou si member n'en fait pas partie,
+// sans distinguer les deux cas.
+func (s *WorkspaceService) Membership(slug, member string) (*models.Workspace, string, error) {
+	workspace, err := s.GetWorkspace(slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrNotMember
+		}
+		return nil, "", err
+	}
+	m, err := s.workspaceRepo.GetMember(workspace.ID, member)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrNotMember
+		}
+		return nil, "", err
+	}
+	return workspace, m.Role, nil
+}
+
+// Members retourne l'espace de travail et ses membres.
+// Il renvoie gorm.ErrRecordNotFound si l'espace n'existe pas.
+func (s *WorkspaceService) Members(slug string) (*models.Workspace, []models.WorkspaceMember, error) {
+	workspace, err := s.GetWorkspace(slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.workspaceRepo.ListMembers(workspace.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return workspace, members, nil
+}
+
+// SetMember ajoute un membre à un espace de travail ou change son rôle.
+// Le dernier propriétaire d'un espace ne peut pas être rétrogradé.
+func (s *WorkspaceService) SetMember(slug, member, role string) (*models.WorkspaceMember, error) {
+	if !isValidMember(member) {
+		return nil, ErrInvalidMember
+	}
+	if !IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	workspace, err := s.GetWorkspace(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.workspaceRepo.GetMember(workspace.ID, member)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil && existing.Role == models.WorkspaceRoleOwner && role != models.WorkspaceRoleOwner {
+		if err := s.keepOwner(workspace.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &models.WorkspaceMember{WorkspaceID: workspace.ID, Member: member, Role: role}
+	if err := s.workspaceRepo.SaveMember(m); err != nil {
+		return nil, fmt.Errorf("failed to save workspace member: %w", err)
+	}
+	return m, nil
+}
+
+// RemoveMember retire un membre d'un espace de travail. Le dernier propriétaire ne peut pas être retiré.
+// Il renvoie gorm.ErrRecordNotFound si l'espace n'existe pas ou si member n'en fait pas partie.
+func (s *WorkspaceService) RemoveMember(slug, member string) error {
+	workspace, err := s.GetWorkspace(slug)
+	if err != nil {
+		return err
+	}
+	m, err := s.workspaceRepo.GetMember(workspace.ID, member)
+	if err != nil {
+		return err
+	}
+	if m.Role == models.WorkspaceRoleOwner {
+		if err := s.keepOwner(workspace.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.workspaceRepo.DeleteMember(m); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	return nil
+}
+
+// keepOwner renvoie ErrLastOwner si l'espace n'a qu'un propriétaire.
+func (s *WorkspaceService) keepOwner(workspaceID uint) error {
+	owners, err := s.workspaceRepo.CountOwners(workspaceID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// isValidMember indique si member désigne une clé d'API ("key:<nom>") ou un utilisateur
+// JWT ("user:<utilisateur>"). Le préfixe évite qu'un utilisateur dont le nom est celui d'une
+// clé d'API hérite de ses rôles.
+func isValidMember(member string) bool {
+	if len(member) > 100 {
+		return false
+	}
+	kind, name, ok := strings.Cut(member, ":")
+	return ok && name != "" && (kind == "key" || kind == "user")
+}
+
+// findLink récupère un lien par son domaine et son code court et vérifie que l'auteur peut
+// exercer perm sur ce lien.
+func findLink(repo repository.LinkRepository, actor Actor, domain, shortCode string, perm Permission) (*models.Link, error) {
+	link, err := repo.GetLinkByShortCode(domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(actor, link, perm); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
@@ -0,0 +1,91 @@
+package useragent
+
+import "strings"
+
+// Classes d'appareils reconnues.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// Systèmes d'exploitation reconnus.
+const (
+	OSiOS     = "ios"
+	OSAndroid = "android"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	OSLinux   = "linux"
+	OSOther   = "other"
+)
+
+// Info est le résultat de l'analyse d'un User-Agent.
+type Info struct {
+	OS     string
+	Device string
+}
+
+// Parse extrait le système d'exploitation et la classe d'appareil d'un User-Agent.
+// L'analyse repose sur quelques marqueurs bien connus : elle vise à classer le trafic
+// pour les statistiques et le ciblage, pas à identifier précisément un navigateur.
+func Parse(ua string) Info {
+	if ua == "" {
+		return Info{OS: OSOther, Device: DeviceUnknown}
+	}
+	s := strings.ToLower(ua)
+
+	if containsAny(s, "bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client") {
+		return Info{OS: OSOther, Device: DeviceBot}
+	}
+
+	switch {
+	case containsAny(s, "ipad"):
+		return Info{OS: OSiOS, Device: DeviceTablet}
+	case containsAny(s, "iphone", "ipod"):
+		return Info{OS: OSiOS, Device: DeviceMobile}
+	case strings.Contains(s, "android"):
+		// Les tablettes Android n'annoncent pas "Mobile" dans leur User-Agent.
+		if strings.Contains(s, "mobile") {
+			return Info{OS: OSAndroid, Device: DeviceMobile}
+		}
+		return Info{OS: OSAndroid, Device: DeviceTablet}
+	case strings.Contains(s, "windows phone"):
+		return Info{OS: OSWindows, Device: DeviceMobile}
+	case strings.Contains(s, "windows"):
+		return Info{OS: OSWindows, Device: DeviceDesktop}
+	case containsAny(s, "macintosh", "mac os x"):
+		return Info{OS: OSMacOS, Device: DeviceDesktop}
+	case containsAny(s, "linux", "x11", "cros"):
+		return Info{OS: OSLinux, Device: DeviceDesktop}
+	}
+	return Info{OS: OSOther, Device: DeviceUnknown}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKnownOS indique si os est l'un des systèmes d'exploitation retournés par Parse.
+func IsKnownOS(os string) bool {
+	switch os {
+	case OSiOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSOther:
+		return true
+	}
+	return false
+}
+
+// IsKnownDevice indique si device est l'une des classes d'appareils retournées par Parse.
+func IsKnownDevice(device string) bool {
+	switch device {
+	case DeviceDesktop, DeviceMobile, DeviceTablet, DeviceBot, DeviceUnknown:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/netutil"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// En-têtes ajoutés à chaque envoi.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-Id"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxErrorLength limite la taille de l'erreur conservée pour un envoi.
+const maxErrorLength = 255
+
+// Options paramètre la livraison des webhooks.
+type Options struct {
+	Interval    time.Duration // Délai maximal entre deux lectures de la file
+	BatchSize   int           // Nombre d'envois lus à chaque passage
+	Workers     int           // Nombre d'envois simultanés
+	MaxAttempts int           // Nombre de tentatives avant le passage en lettre morte
+	BackoffBase time.Duration // Délai avant la deuxième tentative, doublé à chaque échec
+	BackoffMax  time.Duration // Délai maximal entre deux tentatives
+}
+
+// Dispatcher livre les envois en file aux webhooks abonnés. Un envoi échoué (erreur réseau
+// ou réponse hors 2xx) est retenté avec un délai exponentiel, puis déplacé dans les lettres
+// mortes après MaxAttempts tentatives.
+type Dispatcher struct {
+	webhooks    *services.WebhookService
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	opts        Options
+}
+
+// NewDispatcher crée un Dispatcher. client doit être un client durci (voir netutil.NewSafeClient),
+// sauf si les webhooks doivent pouvoir viser le réseau interne. L'intervalle et les délais
+// entre deux tentatives doivent être strictement positifs.
+func NewDispatcher(webhooks *services.WebhookService, webhookRepo repository.WebhookRepository, client *http.Client, opts Options) (*Dispatcher, error) {
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("invalid webhook interval %v", opts.Interval)
+	}
+	if opts.BackoffBase <= 0 || opts.BackoffMax <= 0 {
+		return nil, fmt.Errorf("invalid webhook backoff %v (max %v)", opts.BackoffBase, opts.BackoffMax)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	return &Dispatcher{
+		webhooks:    webhooks,
+		webhookRepo: webhookRepo,
+		client:      client,
+		opts:        opts,
+	}, nil
+}
+
+// Start lance la boucle de livraison. Elle est bloquante et doit être appelée dans une goroutine.
+// La file est lue à chaque intervalle, et dès qu'un événement est publié par ce processus.
+func (d *Dispatcher) Start() {
+	log.Printf("[WEBHOOK] Livraison des webhooks (%d tentative(s) au plus, %d envoi(s) simultané(s)).",
+		d.opts.MaxAttempts, d.opts.Workers)
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.runAndLog()
+		select {
+		case <-ticker.C:
+		case <-d.webhooks.Pending():
+		}
+	}
+}
+
+func (d *Dispatcher) runAndLog() {
+	for {
+		count, err := d.RunOnce(time.Now())
+		if err != nil {
+			log.Printf("[WEBHOOK] ERREUR lors de la lecture des envois en attente : %v", err)
+			return
+		}
+		if count < d.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// RunOnce tente les envois échus à l'instant now et retourne le nombre d'envois traités.
+func (d *Dispatcher) RunOnce(now time.Time) (int, error) {
+	deliveries, err := d.webhookRepo.FindDueDeliveries(now.UTC(), d.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	webhooks, err := d.webhookRepo.ListWebhooks()
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[uint]*models.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+
+	sem := make(chan struct{}, d.opts.Workers)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := byID[delivery.WebhookID]
+		if !ok {
+			// Abonnement supprimé pendant la lecture de la file : l'envoi n'a plus de destinataire.
+			if err := d.webhookRepo.DeleteDelivery(delivery); err != nil {
+				log.Printf("[WEBHOOK] ERREUR lors du retrait de l'envoi #%d : %v", delivery.ID, err)
+			}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.attempt(webhook, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// attempt effectue une tentative d'envoi et enregistre son résultat.
+func (d *Dispatcher) attempt(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	err := d.send(webhook, delivery, time.Now())
+	delivery.Attempts++
+	if err == nil {
+		if err := d.webhookRepo.DeleteDelivery(delivery); err != nil {
+			log.Printf("[WEBHOOK] ERREUR lors du retrait de l'envoi #%d : %v", delivery.ID, err)
+		}
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), maxErrorLength)
+	if delivery.Attempts >= d.opts.MaxAttempts {
+		log.Printf("[WEBHOOK] Envoi #%d (%s) vers le webhook #%d abandonné après %d tentative(s) : %v",
+			delivery.ID, delivery.Event, webhook.ID, delivery.Attempts, err)
+		dead := &models.WebhookDeadLetter{
+			WebhookID: delivery.WebhookID,
+			EventID:   delivery.EventID,
+			Event:     delivery.Event,
+			Payload:   delivery.Payload,
+			Attempts:  delivery.Attempts,
+			LastError: delivery.LastError,
+			FailedAt:  time.Now().UTC(),
+		}
+		if err := d.webhookRepo.KillDelivery(delivery, dead); err != nil {
+			log.Printf("[WEBHOOK] ERREUR lors du passage en lettre morte de l'envoi #%d : %v", delivery.ID, err)
+		}
+		return
+	}
+
+	delivery.NextAttemptAt = time.Now().UTC().Add(d.backoff(delivery.Attempts))
+	log.Printf("[WEBHOOK] Échec de l'envoi #%d (%s) vers le webhook #%d (tentative %d/%d), nouvel essai à %s : %v",
+		delivery.ID, delivery.Event, webhook.ID, delivery.Attempts, d.opts.MaxAttempts,
+		delivery.NextAttemptAt.Format(time.RFC3339), err)
+	if err := d.webhookRepo.RetryDelivery(delivery); err != nil {
+		log.Printf("[WEBHOOK] ERREUR lors de la reprogrammation de l'envoi #%d : %v", delivery.ID, err)
+	}
+}
+
+// send poste le corps de l'envoi au webhook. Seule une réponse 2xx vaut réussite.
+func (d *Dispatcher) send(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) error {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderID, delivery.EventID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer netutil.DrainBody(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff retourne le délai avant la tentative suivant la n-ième : BackoffBase, puis le double
+// à chaque échec, dans la limite de BackoffMax.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BackoffBase
+	for i := 1; i < attempts && delay < d.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	if d.opts.BackoffMax > 0 && delay > d.opts.BackoffMax {
+		delay = d.opts.BackoffMax
+	}
+	return delay
+}
+
+// Sign calcule l'en-tête X-Webhook-Signature d'un envoi : "t=<horodatage unix>,v1=<signature>",
+// où la signature est le HMAC-SHA256, en hexadécimal, de "<horodatage>.<corps>" avec le secret
+// du webhook. L'horodatage signé permet au destinataire de refuser les envois rejoués.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
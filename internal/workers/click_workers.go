@@ -2,54 +2,56 @@ package workers
 
 import (
 	"log"
-	"time"
 
+	"github.com/axellelanca/urlshortener/internal/geoip"
 	"github.com/axellelanca/urlshortener/internal/models"
-	"github.com/axellelanca/urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
+	"github.com/axellelanca/urlshortener/internal/netutil"
 )
 
 // StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
-// Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
+// Chaque worker lira depuis le même 'clickEventsChan' et transmettra chaque clic à toutes les
+// destinations de 'sinks' (base de données, fichier, service HTTP...).
+// locator localise l'IP de chaque clic ; il peut être nil si la géolocalisation est désactivée.
+// anonymizer réduit ensuite l'IP selon le mode de conservation configuré, avant sa transmission.
+func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, sinks []ClickSink, locator *geoip.Locator, anonymizer *netutil.IPAnonymizer) {
+	log.Printf("Starting %d click worker(s) writing to %v...", workerCount, SinkNames(sinks))
 	for i := 0; i < workerCount; i++ {
 		// Lance chaque worker dans sa propre goroutine.
 		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
-		go clickWorker(clickEventsChan, clickRepo)
+		go clickWorker(clickEventsChan, sinks, locator, anonymizer)
 	}
 }
 
 // clickWorker est la fonction exécutée par chaque goroutine worker.
 // Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
+func clickWorker(clickEventsChan <-chan models.ClickEvent, sinks []ClickSink, locator *geoip.Locator, anonymizer *netutil.IPAnonymizer) {
 	for event := range clickEventsChan { // Boucle qui lit les événements du channel
-		//  Convertir le 'ClickEvent' (reçu du channel) en un modèle 'models.Click'.
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IpAddress,
-			Timestamp: event.Timestamp,
+		//  Convertir le 'ClickEvent' (reçu du channel) en un 'ClickRecord' commun à toutes les destinations.
+		record := &ClickRecord{
+			LinkID:      event.LinkID,
+			Domain:      event.Domain,
+			ShortCode:   event.ShortCode,
+			WorkspaceID: event.WorkspaceID,
+			UserAgent:   event.UserAgent,
+			IPAddress:   anonymizer.Anonymize(event.IpAddress),
+			Timestamp:   event.Timestamp,
+			Referrer:    event.Referrer,
+			Source:      event.Source,
+			Version:     event.Version,
+			Variant:     event.Variant,
 		}
 
-		// Persiste le clic en base de données
-		// logique de retry implémentée
-		maxRetries := 3
-		retryDelay := time.Millisecond * 200
-		var err error
-		for i := 1; i <= maxRetries; i++ {
-			err = clickRepo.CreateClick(click)
-			if err == nil {
-				log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
-				break // ✅ Success
-			}
+		// Localise le visiteur hors du chemin de la redirection, la recherche restant locale.
+		// L'adresse complète n'est utilisée que pour cette recherche, jamais transmise en mode réduit.
+		location := locator.Lookup(event.IpAddress)
+		record.Country = location.Country
+		record.Region = location.Region
 
-			if i == maxRetries {
-				log.Printf("ERROR: Failed to save click after %d attempts: %v", maxRetries, err)
-			} else {
-				log.Printf("WARN: Failed to save click (attempt %d/%d): %v", i, maxRetries, err)
+		// Une destination en échec n'empêche pas l'écriture vers les autres.
+		for _, sink := range sinks {
+			if err := sink.Write(record); err != nil {
+				log.Printf("ERROR: Failed to write click for LinkID %d to sink %s: %v", event.LinkID, sink.Name(), err)
 			}
-			time.Sleep(retryDelay)
-
 		}
 	}
 }
@@ -0,0 +1,106 @@
+package workers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTooManySubscribers est retournée lorsque le nombre maximal d'abonnés au flux de clics est atteint.
+var ErrTooManySubscribers = errors.New("too many click stream subscribers")
+
+// ClickHub diffuse les clics traités par les workers aux abonnés du flux en direct (SSE).
+// C'est une destination des clics : chaque abonné dispose de sa propre file, et un clic qui
+// ne tient plus dans la file d'un abonné trop lent est perdu pour lui seul, sans jamais
+// bloquer les workers.
+type ClickHub struct {
+	bufferSize     int
+	maxSubscribers int
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// Subscription est l'abonnement d'un client au flux de clics.
+type Subscription struct {
+	// C reçoit les clics retenus par le filtre de l'abonnement ; il est fermé par Unsubscribe
+	// ou à l'arrêt du hub.
+	C <-chan *ClickRecord
+
+	c       chan *ClickRecord
+	filter  func(*ClickRecord) bool
+	dropped atomic.Int64
+}
+
+// Dropped retourne le nombre de clics perdus depuis le dernier appel, faute de place dans la file.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// NewClickHub crée un hub dont chaque abonné peut avoir bufferSize clics en attente.
+// maxSubscribers <= 0 ne limite pas le nombre d'abonnés.
+func NewClickHub(bufferSize, maxSubscribers int) *ClickHub {
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+	return &ClickHub{
+		bufferSize:     bufferSize,
+		maxSubscribers: maxSubscribers,
+		subscribers:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe abonne un client aux clics retenus par filter.
+func (h *ClickHub) Subscribe(filter func(*ClickRecord) bool) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || (h.maxSubscribers > 0 && len(h.subscribers) >= h.maxSubscribers) {
+		return nil, ErrTooManySubscribers
+	}
+	c := make(chan *ClickRecord, h.bufferSize)
+	sub := &Subscription{C: c, c: c, filter: filter}
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe retire un abonnement et ferme son canal. Il peut être appelé plusieurs fois.
+func (h *ClickHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.c)
+	}
+}
+
+func (h *ClickHub) Name() string { return "stream" }
+
+// Write transmet le clic aux abonnés concernés, sans attendre ceux dont la file est pleine.
+func (h *ClickHub) Write(record *ClickRecord) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if !sub.filter(record) {
+			continue
+		}
+		select {
+		case sub.c <- record:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Close met fin à tous les abonnements.
+func (h *ClickHub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.c)
+	}
+	h.closed = true
+	return nil
+}
@@ -0,0 +1,126 @@
+package workers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// ClickRecord est un clic traité par les workers : l'IP est déjà réduite selon le mode
+// analytics.ip_mode et le visiteur localisé. C'est aussi la ligne JSON écrite par les
+// destinations fichier, sortie standard et HTTP.
+type ClickRecord struct {
+	LinkID    uint   `json:"link_id"`
+	Domain    string `json:"domain"`
+	ShortCode string `json:"short_code"`
+	// WorkspaceID sert au filtrage du flux de clics en direct ; il n'est pas écrit par les destinations.
+	WorkspaceID uint      `json:"-"`
+	Timestamp   time.Time `json:"timestamp"`
+	IPAddress   string    `json:"ip,omitempty"`
+	UserAgent   string    `json:"user_agent"`
+	Referrer    string    `json:"referrer"`
+	Source      string    `json:"source"`
+	Country     string    `json:"country"`
+	Region      string    `json:"region"`
+	Version     int       `json:"version"`
+	Variant     string    `json:"variant"`
+}
+
+// ClickSink est une destination des clics. Les workers appellent Write pour chaque clic,
+// depuis plusieurs goroutines : une implémentation doit être sûre en accès concurrent.
+// Une erreur de Write est signalée dans les logs sans empêcher l'écriture vers les autres destinations.
+type ClickSink interface {
+	Name() string
+	Write(record *ClickRecord) error
+	// Close écrit les clics encore en mémoire et libère les ressources, à l'arrêt du serveur.
+	Close() error
+}
+
+// NewClickSinks construit les destinations activées dans la section click_sinks de la
+// configuration. webhooks, s'il est fourni, reçoit en plus l'événement click.recorded de chaque clic.
+func NewClickSinks(cfg *config.Config, clickRepo repository.ClickRepository, webhooks *services.WebhookService) ([]ClickSink, error) {
+	sinksCfg := cfg.ClickSinks
+	var sinks []ClickSink
+
+	if sinksCfg.Database.Enabled {
+		sinks = append(sinks, NewDatabaseSink(clickRepo))
+	}
+	if sinksCfg.File.Enabled {
+		sink, err := NewFileSink(sinksCfg.File.Path, int64(sinksCfg.File.MaxSizeMB)<<20, sinksCfg.File.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("click_sinks.file: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if sinksCfg.Stdout.Enabled {
+		sinks = append(sinks, NewWriterSink("stdout", os.Stdout))
+	}
+	if sinksCfg.HTTP.Enabled {
+		httpCfg := sinksCfg.HTTP
+		sink, err := NewHTTPSink(HTTPSinkOptions{
+			URL:           httpCfg.URL,
+			Headers:       httpCfg.Headers,
+			BatchSize:     httpCfg.BatchSize,
+			FlushInterval: time.Duration(httpCfg.FlushIntervalSeconds) * time.Second,
+			Timeout:       time.Duration(httpCfg.TimeoutSeconds) * time.Second,
+			MaxAttempts:   httpCfg.MaxAttempts,
+			MaxBuffer:     httpCfg.MaxBuffer,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("click_sinks.http: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if webhooks != nil {
+		sinks = append(sinks, &webhookSink{webhooks: webhooks})
+	}
+	return sinks, nil
+}
+
+// CloseSinks ferme toutes les destinations, en signalant les erreurs dans les logs.
+func CloseSinks(sinks []ClickSink) {
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("ERROR: Failed to close click sink %s: %v", sink.Name(), err)
+		}
+	}
+}
+
+// SinkNames retourne le nom des destinations, pour les logs.
+func SinkNames(sinks []ClickSink) []string {
+	names := make([]string, 0, len(sinks))
+	for _, sink := range sinks {
+		names = append(names, sink.Name())
+	}
+	return names
+}
+
+// webhookSink publie l'événement de webhook click.recorded de chaque clic.
+type webhookSink struct {
+	webhooks *services.WebhookService
+}
+
+func (s *webhookSink) Name() string { return "webhooks" }
+
+func (s *webhookSink) Write(record *ClickRecord) error {
+	s.webhooks.Publish(models.WebhookEventClickRecorded, services.ClickEventData{
+		LinkID:    record.LinkID,
+		Domain:    record.Domain,
+		ShortCode: record.ShortCode,
+		Timestamp: record.Timestamp,
+		Referrer:  record.Referrer,
+		Source:    record.Source,
+		Country:   record.Country,
+		Version:   record.Version,
+		Variant:   record.Variant,
+	})
+	return nil
+}
+
+func (s *webhookSink) Close() error { return nil }
@@ -0,0 +1,57 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// DatabaseSink enregistre les clics dans la table 'clicks', d'où sont tirées les statistiques.
+type DatabaseSink struct {
+	clickRepo repository.ClickRepository
+}
+
+// NewDatabaseSink crée la destination base de données.
+func NewDatabaseSink(clickRepo repository.ClickRepository) *DatabaseSink {
+	return &DatabaseSink{clickRepo: clickRepo}
+}
+
+func (s *DatabaseSink) Name() string { return "database" }
+
+// Write persiste le clic, en retentant quelques fois en cas d'échec.
+func (s *DatabaseSink) Write(record *ClickRecord) error {
+	click := &models.Click{
+		LinkID:    record.LinkID,
+		UserAgent: record.UserAgent,
+		IPAddress: record.IPAddress,
+		Timestamp: record.Timestamp,
+		Referrer:  record.Referrer,
+		Source:    record.Source,
+		Country:   record.Country,
+		Region:    record.Region,
+		Version:   record.Version,
+		Variant:   record.Variant,
+	}
+
+	// logique de retry implémentée
+	maxRetries := 3
+	retryDelay := time.Millisecond * 200
+	var err error
+	for i := 1; i <= maxRetries; i++ {
+		err = s.clickRepo.CreateClick(click)
+		if err == nil {
+			log.Printf("Click recorded successfully for LinkID %d", record.LinkID)
+			return nil // ✅ Success
+		}
+
+		if i < maxRetries {
+			log.Printf("WARN: Failed to save click (attempt %d/%d): %v", i, maxRetries, err)
+			time.Sleep(retryDelay)
+		}
+	}
+	return err
+}
+
+func (s *DatabaseSink) Close() error { return nil }
@@ -0,0 +1,176 @@
+package workers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/netutil"
+)
+
+// ErrSinkBufferFull est retournée lorsqu'une destination HTTP a trop de clics en attente d'envoi.
+var ErrSinkBufferFull = errors.New("click sink buffer is full")
+
+// HTTPSinkOptions paramètre la destination HTTP.
+type HTTPSinkOptions struct {
+	URL           string
+	Headers       map[string]string // En-têtes ajoutés à chaque envoi, par exemple Authorization
+	BatchSize     int               // Nombre de clics déclenchant un envoi
+	FlushInterval time.Duration     // Délai maximal avant l'envoi d'un lot incomplet
+	Timeout       time.Duration
+	MaxAttempts   int // Tentatives par lot avant son abandon
+	MaxBuffer     int // Nombre maximal de clics en attente, au-delà les nouveaux clics sont perdus
+}
+
+// HTTPSink envoie les clics par lots, en POST d'un document {"clicks": [...]}, à un service
+// tiers. Un lot refusé (erreur réseau ou réponse hors 2xx) est retenté avec un délai
+// croissant, puis abandonné ; les clics reçus pendant ce temps restent en mémoire.
+type HTTPSink struct {
+	opts   HTTPSinkOptions
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []*ClickRecord
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewHTTPSink crée la destination HTTP et lance sa goroutine d'envoi.
+func NewHTTPSink(opts HTTPSinkOptions) (*HTTPSink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", opts.URL)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.MaxBuffer < opts.BatchSize {
+		opts.MaxBuffer = opts.BatchSize
+	}
+
+	s := &HTTPSink{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *HTTPSink) Name() string { return "http" }
+
+// Write ajoute le clic au lot en cours ; l'envoi est déclenché dès que le lot est complet.
+func (s *HTTPSink) Write(record *ClickRecord) error {
+	s.mu.Lock()
+	if len(s.pending) >= s.opts.MaxBuffer {
+		s.mu.Unlock()
+		return ErrSinkBufferFull
+	}
+	s.pending = append(s.pending, record)
+	full := len(s.pending) >= s.opts.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close arrête la goroutine d'envoi après un dernier envoi des clics en attente.
+func (s *HTTPSink) Close() error {
+	close(s.done)
+	<-s.stopped
+	return nil
+}
+
+// run envoie les lots complets dès qu'ils le sont, et les lots incomplets à chaque intervalle.
+func (s *HTTPSink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flush:
+		case <-s.done:
+			s.sendPending()
+			return
+		}
+		s.sendPending()
+	}
+}
+
+// sendPending envoie les clics en attente, par lots de BatchSize.
+func (s *HTTPSink) sendPending() {
+	for {
+		s.mu.Lock()
+		n := min(len(s.pending), s.opts.BatchSize)
+		batch := s.pending[:n:n]
+		s.pending = s.pending[n:]
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+
+		if err := s.sendWithRetry(batch); err != nil {
+			log.Printf("ERROR: click sink http: %d click(s) dropped after %d attempt(s): %v", n, s.opts.MaxAttempts, err)
+		}
+	}
+}
+
+func (s *HTTPSink) sendWithRetry(batch []*ClickRecord) error {
+	body, err := json.Marshal(map[string][]*ClickRecord{"clicks": batch})
+	if err != nil {
+		return err
+	}
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		err = s.send(body)
+		if err == nil || attempt >= s.opts.MaxAttempts {
+			return err
+		}
+		log.Printf("WARN: click sink http: failed to send %d click(s) (attempt %d/%d): %v", len(batch), attempt, s.opts.MaxAttempts, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *HTTPSink) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer netutil.DrainBody(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedSuffixFormat horodate les fichiers de clics archivés lors d'une rotation.
+const rotatedSuffixFormat = "20060102-150405.000"
+
+// WriterSink écrit chaque clic sur une ligne JSON (NDJSON) d'un io.Writer, par exemple la
+// sortie standard.
+type WriterSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterSink crée une destination NDJSON écrivant dans w.
+func NewWriterSink(name string, w io.Writer) *WriterSink {
+	return &WriterSink{name: name, w: w}
+}
+
+func (s *WriterSink) Name() string { return s.name }
+
+func (s *WriterSink) Write(record *ClickRecord) error {
+	line, err := marshalLine(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return err
+}
+
+func (s *WriterSink) Close() error { return nil }
+
+// FileSink écrit chaque clic sur une ligne JSON d'un fichier. Lorsque le fichier dépasse
+// maxBytes, il est renommé avec un horodatage (clicks-20240102-150405.000.ndjson) et un
+// nouveau fichier est ouvert ; seules les maxBackups archives les plus récentes sont conservées.
+type FileSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink ouvre, en ajout, le fichier de clics path, en créant son répertoire si besoin.
+// maxBytes <= 0 désactive la rotation ; maxBackups <= 0 conserve toutes les archives.
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	s := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+func (s *FileSink) Write(record *ClickRecord) error {
+	line, err := marshalLine(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("file sink is closed")
+	}
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", s.path, err)
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// open ouvre le fichier courant en ajout et reprend sa taille.
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate archive le fichier courant, en ouvre un nouveau et supprime les archives en trop.
+// Il doit être appelé avec s.mu verrouillé.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	base, ext := splitExt(s.path)
+	archive := base + "-" + time.Now().UTC().Format(rotatedSuffixFormat) + ext
+	if err := os.Rename(s.path, archive); err != nil {
+		return err
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.prune(base, ext)
+}
+
+// prune supprime les archives les plus anciennes au-delà de maxBackups. Le format de
+// l'horodatage les trie par ordre chronologique.
+func (s *FileSink) prune(base, ext string) error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+	archives, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	sort.Strings(archives)
+	for len(archives) > s.maxBackups {
+		if err := os.Remove(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+// splitExt sépare un chemin de son extension : "clicks/clicks.ndjson" donne "clicks/clicks" et ".ndjson".
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext), ext
+}
+
+func marshalLine(record *ClickRecord) ([]byte, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}